@@ -2,28 +2,32 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	esv7 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/gorilla/mux"
-	_ "github.com/lib/pq"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	"go.uber.org/zap"
 
 	_ "filmoteka/docs"
+	"filmoteka/internal"
 	elasticsearch "filmoteka/internal/app/elacticsearch"
 	"filmoteka/internal/app/service"
 	"filmoteka/internal/envvar"
+	"filmoteka/internal/metrics"
 	"filmoteka/internal/restapi"
 	"filmoteka/internal/storage/postgresql"
+	"filmoteka/internal/tracing"
 )
 
 //	@title			Swagger filmoteka API
@@ -33,12 +37,30 @@ import (
 //  @host           localhost:8080
 
 func main() {
-	var env string
-
-	flag.StringVar(&env, "env", "", "Environment Variables filename")
+	var (
+		env         string
+		config      string
+		printConfig bool
+	)
+
+	flag.StringVar(&env, "env", "", "Environment Variables filename, .env by default")
+	flag.StringVar(&config, "config", "", "YAML configuration filename, environment variables take precedence")
+	flag.BoolVar(&printConfig, "print-config", false, "Print the configuration with the secrets redacted and exit")
 	flag.Parse()
 
-	errC, err := run(env)
+	conf, err := loadConfig(env, config)
+	if err != nil {
+		log.Fatalf("Couldn't load configuration: %s", err)
+	}
+
+	if printConfig {
+		if err := conf.Print(os.Stdout); err != nil {
+			log.Fatalf("Couldn't print configuration: %s", err)
+		}
+		return
+	}
+
+	errC, err := run(conf)
 	if err != nil {
 		log.Fatalf("Couldn't run: %s", err)
 	}
@@ -49,28 +71,30 @@ func main() {
 
 }
 
-func run(env string) (<-chan error, error) {
+func run(conf *envvar.Config) (<-chan error, error) {
 	logger, err := zap.NewProduction()
 	if err != nil {
 		return nil, fmt.Errorf("zap.NewProduction %w", err)
 	}
 
-	if err := envvar.Load(env); err != nil {
-		return nil, fmt.Errorf("envvar.Load %w", err)
+	shutdownTracing, err := tracing.Setup(context.Background(), conf.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("tracing.Setup %w", err)
 	}
 
-	conf := envvar.New()
-
-	db, err := newDB(conf)
+	db, err := postgresql.Connect(conf.Database)
 	if err != nil {
-		return nil, fmt.Errorf("newDB %w", err)
+		return nil, fmt.Errorf("postgresql.Connect %w", err)
 	}
 
-	es, err := newElasticSearch(conf)
+	es, err := elasticsearch.NewClient(conf.Search)
 	if err != nil {
-		return nil, fmt.Errorf("newElasticSearch %w", err)
+		return nil, fmt.Errorf("elasticsearch.NewClient %w", err)
 	}
 
+	search := elasticsearch.NewFilmSearchRepo(es, conf.Search.FilmsIndex)
+	actorsSearch := elasticsearch.NewActorSearchRepo(es, conf.Search.ActorsIndex)
+
 	logging := func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			logger.Info(r.Method,
@@ -82,38 +106,85 @@ func run(env string) (<-chan error, error) {
 		})
 	}
 
-	serverAddr, err := conf.Get("BIND_ADDR")
-	if err != nil {
-		return nil, fmt.Errorf("config error: %w", err)
-	}
-
 	errC := make(chan error, 1)
 
-	srv := newServer(conf, db, es, logging)
+	telemetry := metrics.New(db)
+
+	// NOTE: Searches and deliveries share the breaker, both fail fast while Elasticsearch is down.
+	resilientSearch := service.NewResilientFilmSearch("elasticsearch", search, telemetry)
+	resilientActorsSearch := service.NewResilientActorSearch(actorsSearch, resilientSearch)
+
+	relay := service.NewSearchRelay(
+		postgresql.NewOutbox(db),
+		conf.Search.OutboxMaxAttempts,
+		postgresql.NewFilm(db),
+		resilientSearch,
+		postgresql.NewActor(db),
+		resilientActorsSearch,
+		logger)
+
+	telemetry.WatchSearch(relay, resilientSearch)
+
+	// NOTE: Film searches are served by Postgres while Elasticsearch is unavailable.
+	filmsSearch := service.NewFailoverSearch(resilientSearch, postgresql.NewFilmSearch(db), logger)
+
+	var mapping searchMapping
+
+	health := service.NewHealthService(
+		service.HealthCheck{Name: "postgres", Critical: true, Check: db.PingContext},
+		service.HealthCheck{Name: "elasticsearch", Check: search.Ping},
+		service.HealthCheck{Name: "elasticsearch_breaker", Check: resilientSearch.Check},
+		service.HealthCheck{Name: "elasticsearch_mapping", Check: mapping.Check},
+	)
+
+	srv := newServer(conf, db, filmsSearch, resilientSearch, resilientActorsSearch, relay, health, telemetry, logging)
 
 	ctx, stop := signal.NotifyContext(context.Background(),
 		os.Interrupt,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	relayDone := make(chan struct{})
+
+	go func() {
+		defer close(relayDone)
+
+		// NOTE: Nothing is indexed until the indices exist, the outbox keeps the changes meanwhile.
+		if err := bootstrapSearch(ctx, logger, search, actorsSearch); err != nil {
+			// NOTE: The indices have another mapping, e.g. older pods during a rolling deploy. The
+			// rest of the API is still served, the reindex command rebuilds them.
+			if ctx.Err() == nil {
+				logger.Error("Search indices have another mapping, changes aren't indexed", zap.Error(err))
+				mapping.Fail(err)
+			}
+			return
+		}
+
+		relay.Run(ctx)
+	}()
+
 	go func() {
 		<-ctx.Done()
 
 		logger.Info("Shutdown signal received")
 
-		ctxTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-
 		defer func() {
+			<-relayDone // NOTE: The relay uses the database until it stops.
+
+			ctxTimeout, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+			defer cancel()
+
+			if err := shutdownTracing(ctxTimeout); err != nil {
+				logger.Error("Flushing traces failed", zap.Error(err))
+			}
+
 			logger.Sync()
 			db.Close()
 			stop()
-			cancel()
 			close(errC)
 		}()
 
-		srv.SetKeepAlivesEnabled(false)
-
-		if err := srv.Shutdown(ctxTimeout); err != nil {
+		if err := shutdown(health, srv, conf.Server.ShutdownDelay, conf.Server.ShutdownTimeout); err != nil {
 			errC <- err
 		}
 
@@ -121,7 +192,7 @@ func run(env string) (<-chan error, error) {
 	}()
 
 	go func() {
-		logger.Info("Listening and serving", zap.String("address", serverAddr))
+		logger.Info("Listening and serving", zap.String("address", conf.Server.BindAddr))
 
 		// "ListenAndServe always returns a non-nil error. After Shutdown or Close, the returned error is
 		// ErrServerClosed."
@@ -133,37 +204,64 @@ func run(env string) (<-chan error, error) {
 	return errC, nil
 }
 
-func newServer(conf *envvar.Configuration, db *sql.DB, es *esv7.Client, mws ...mux.MiddlewareFunc) *http.Server {
+// shutdown stops srv gracefully: health reports not ready first, requests are still served for
+// delay while load balancers notice it, and then srv stops accepting them and waits up to timeout
+// for the in-flight ones to complete.
+func shutdown(health interface{ Shutdown() }, srv interface {
+	SetKeepAlivesEnabled(v bool)
+	Shutdown(ctx context.Context) error
+}, delay, timeout time.Duration) error {
+	// NOTE: Not ready anymore, so no new traffic is routed here while requests complete.
+	health.Shutdown()
+
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	srv.SetKeepAlivesEnabled(false)
+
+	return srv.Shutdown(ctx)
+}
+
+func newServer(conf *envvar.Config, db *sql.DB, filmsSearch service.FilmSearchRepository,
+	search *service.ResilientFilmSearch, actorsSearch *service.ResilientActorSearch,
+	relay *service.SearchRelay, health restapi.HealthService,
+	telemetry *metrics.Metrics, mws ...mux.MiddlewareFunc) *http.Server {
 	r := mux.NewRouter()
 
+	r.Use(tracing.Middleware, telemetry.Middleware)
+
 	for _, mw := range mws {
 		r.Use(mw)
 	}
 
-	repoFilms := postgresql.NewFilm(db) // Film Repository
-	repoSearch := elasticsearch.NewFilmSearchRepo(es)
-	svcFilms := service.NewFilmService(repoFilms, repoSearch) // Film Service
+	r.Use(restapi.Deadline(conf.Server.RequestTimeout), restapi.LimitBody(int64(conf.Server.MaxBodyBytes)))
 
-	repoActors := postgresql.NewActor(db)            // Actor Repository
-	svcActors := service.NewActorService(repoActors) // Actor Service
+	repoFilms := postgresql.NewFilm(db)                        // Film Repository
+	svcFilms := service.NewFilmService(repoFilms, filmsSearch) // Film Service
 
-	restapi.NewFilmHandler(svcFilms).Register(r)
-	restapi.NewActorHandler(svcActors).Register(r)
+	repoActors := postgresql.NewActor(db)                          // Actor Repository
+	svcActors := service.NewActorService(repoActors, actorsSearch) // Actor Service
 
-	address, err := conf.Get("BIND_ADDR")
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+	repoCast := postgresql.NewCast(db)          // Cast Repository
+	svcCast := service.NewCastService(repoCast) // Cast Service
 
-	swagUrl, err := conf.Get("SWAG_URL")
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+	svcSuggest := service.NewSuggestService(search, actorsSearch) // Suggest Service
 
-	// swagUrl := "./docs/doc.json"
+	cursors := restapi.NewCursors(newCursorSecret(conf.Cursor))
+
+	restapi.NewFilmHandler(svcFilms, cursors).Register(r)
+	restapi.NewActorHandler(svcActors, cursors).Register(r)
+	restapi.NewCastHandler(svcCast).Register(r)
+	restapi.NewSearchRelayHandler(relay).Register(r)
+	restapi.NewCircuitBreakerHandler(search).Register(r)
+	restapi.NewHealthHandler(health).Register(r)
+	restapi.NewSuggestHandler(svcSuggest).Register(r)
+	telemetry.Register(r)
 
 	r.PathPrefix("/docs/").Handler(httpSwagger.Handler(
-		httpSwagger.URL(swagUrl), //The url pointing to API definition
+		httpSwagger.URL(conf.Swagger.URL), //The url pointing to API definition
 		httpSwagger.DeepLinking(true),
 		httpSwagger.DocExpansion("none"),
 		httpSwagger.DomID("swagger-ui"),
@@ -171,43 +269,98 @@ func newServer(conf *envvar.Configuration, db *sql.DB, es *esv7.Client, mws ...m
 
 	return &http.Server{
 		Handler:           r,
-		Addr:              address,
-		ReadTimeout:       1 * time.Second,
-		ReadHeaderTimeout: 1 * time.Second,
-		WriteTimeout:      1 * time.Second,
-		IdleTimeout:       1 * time.Second,
+		Addr:              conf.Server.BindAddr,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+		MaxHeaderBytes:    conf.Server.MaxHeaderBytes,
 	}
 }
 
-func newDB(conf *envvar.Configuration) (*sql.DB, error) {
-	dbUrl, err := conf.Get("DB_URL")
+// loadConfig loads the env file into the environment and returns the configuration.
+func loadConfig(env, filename string) (*envvar.Config, error) {
+	if err := envvar.Load(env); err != nil {
+		return nil, fmt.Errorf("envvar.Load %w", err)
+	}
+
+	secrets, err := envvar.NewSecretsFromEnv()
 	if err != nil {
-		log.Fatal("No Database url. Check config file")
-		return nil, fmt.Errorf("No Database url %w", err)
+		return nil, fmt.Errorf("envvar.NewSecretsFromEnv %w", err)
 	}
-	db, err := sql.Open("postgres", dbUrl)
+
+	conf, err := envvar.LoadConfig(envvar.New(secrets), filename)
 	if err != nil {
-		return nil, fmt.Errorf("sql.Open %w", err)
+		return nil, fmt.Errorf("envvar.LoadConfig %w", err)
+	}
+
+	return conf, nil
+}
+
+// newCursorSecret returns the key used for signing paging cursors. Without a secret a random key
+// is used, so cursors don't survive restarts nor work across replicas.
+func newCursorSecret(conf envvar.CursorConfig) []byte {
+	if conf.Secret != "" {
+		return []byte(conf.Secret)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("db.Ping %w", err)
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err.Error())
 	}
 
-	return db, nil
+	return key
 }
 
-func newElasticSearch(conf *envvar.Configuration) (*esv7.Client, error) {
-	es, err := esv7.NewDefaultClient()
-	if err != nil {
-		return nil, fmt.Errorf("elasticsearch.Open %w", err)
+// searchMapping reports whether the search indices have the mapping of this version, see
+// bootstrapSearch.
+type searchMapping struct {
+	err atomic.Pointer[error]
+}
+
+// Fail records that the indices have another mapping.
+func (s *searchMapping) Fail(err error) {
+	s.err.Store(&err)
+}
+
+// Check fails once the indices were found with another mapping, see HealthCheck.
+func (s *searchMapping) Check(_ context.Context) error {
+	if err := s.err.Load(); err != nil {
+		return *err
 	}
 
-	res, err := es.Info()
-	if err != nil {
-		return nil, fmt.Errorf("es.Info %w", err)
+	return nil
+}
+
+// bootstrapRetry is the wait between attempts to bootstrap the search indices.
+const bootstrapRetry = 5 * time.Second
+
+// bootstrapSearch bootstraps the search indices, retrying until it succeeds or ctx is cancelled.
+// Indices with another mapping aren't retried, see elasticsearch.Bootstrap.
+func bootstrapSearch(ctx context.Context, logger *zap.Logger, indices ...interface {
+	Bootstrap(ctx context.Context) error
+}) error {
+	for _, index := range indices {
+		for {
+			err := index.Bootstrap(ctx)
+			if err == nil {
+				break
+			}
+
+			var ierr *internal.Error
+			if errors.As(err, &ierr) && ierr.Code() == internal.ErrorCodePreconditionFailed {
+				return err
+			}
+
+			logger.Error("search bootstrap failed, retrying", zap.Error(err), zap.Duration("in", bootstrapRetry))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(bootstrapRetry):
+			}
+		}
 	}
-	defer res.Body.Close()
 
-	return es, nil
+	return nil
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/app/service"
+	"filmoteka/internal/restapi"
+)
+
+func TestShutdown(t *testing.T) {
+	const delay = 200 * time.Millisecond
+
+	health := service.NewHealthService(service.HealthCheck{
+		Name:     "postgres",
+		Critical: true,
+		Check:    func(context.Context) error { return nil },
+	})
+
+	r := mux.NewRouter()
+	restapi.NewHealthHandler(health).Register(r)
+	r.HandleFunc("/films", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: r}
+	go srv.Serve(ln)
+
+	url := "http://" + ln.Addr().String()
+
+	status := func(path string) int {
+		resp, err := http.Get(url + path)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, status("/readyz"))
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- shutdown(health, srv, delay, time.Second) }()
+
+	// NOTE: Not ready anymore, requests are still served during the delay.
+	assert.Eventually(t, func() bool { return status("/readyz") == http.StatusServiceUnavailable },
+		delay/2, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, status("/films"))
+
+	require.NoError(t, <-done)
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+
+	assert.Equal(t, 0, status("/films"), "requests must be refused once shut down")
+}
+
+// bootstrapper fails its first bootstraps with errs.
+type bootstrapper struct {
+	errs  []error
+	calls int
+}
+
+func (b *bootstrapper) Bootstrap(context.Context) error {
+	b.calls++
+	if b.calls > len(b.errs) {
+		return nil
+	}
+
+	return b.errs[b.calls-1]
+}
+
+func TestBootstrapSearch(t *testing.T) {
+	t.Run("mapping", func(t *testing.T) {
+		films := &bootstrapper{errs: []error{internal.NewErrorf(internal.ErrorCodePreconditionFailed, "newer")}}
+		actors := &bootstrapper{}
+
+		err := bootstrapSearch(context.Background(), zap.NewNop(), films, actors)
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, films.calls, "mapping errors must not be retried")
+		assert.Equal(t, 0, actors.calls)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		films := &bootstrapper{errs: []error{internal.NewErrorf(internal.ErrorCodeUnknown, "connection refused")}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := bootstrapSearch(ctx, zap.NewNop(), films)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, films.calls)
+	})
+
+	t.Run("bootstrapped", func(t *testing.T) {
+		films, actors := &bootstrapper{}, &bootstrapper{}
+
+		require.NoError(t, bootstrapSearch(context.Background(), zap.NewNop(), films, actors))
+		assert.Equal(t, 1, films.calls)
+		assert.Equal(t, 1, actors.calls)
+	})
+}
+
+func TestSearchMapping(t *testing.T) {
+	var mapping searchMapping
+
+	health := service.NewHealthService(
+		service.HealthCheck{Name: "postgres", Critical: true, Check: func(context.Context) error { return nil }},
+		service.HealthCheck{Name: "elasticsearch_mapping", Check: mapping.Check},
+	)
+
+	assert.Equal(t, models.HealthOK, health.Ready(context.Background()).Status)
+
+	mapping.Fail(internal.NewErrorf(internal.ErrorCodePreconditionFailed, "index films_1 mapping version 2 is older than 3"))
+
+	// NOTE: Degraded, not failing: the rest of the API is still served.
+	res := health.Ready(context.Background())
+	assert.Equal(t, models.HealthDegraded, res.Status)
+	assert.Equal(t, "index films_1 mapping version 2 is older than 3", res.Checks["elasticsearch_mapping"].Error)
+}
@@ -0,0 +1,116 @@
+// Command reindex rebuilds the films search index from the database. Films are bulk indexed into
+// a new versioned index and the `films` alias is swapped to it once it is complete, so searches
+// never see a partial index. It migrates the index to a new mapping version too: the documents
+// are rebuilt from the films and their cast, none is copied from the previous index.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	elasticsearch "filmoteka/internal/app/elacticsearch"
+	"filmoteka/internal/app/service"
+	"filmoteka/internal/envvar"
+	"filmoteka/internal/storage/postgresql"
+	"filmoteka/internal/tracing"
+)
+
+func main() {
+	var (
+		env     string
+		config  string
+		batch   int
+		keepOld bool
+	)
+
+	flag.StringVar(&env, "env", "", "Environment Variables filename, .env by default")
+	flag.StringVar(&config, "config", "", "YAML configuration filename, environment variables take precedence")
+	flag.IntVar(&batch, "batch", 500, "Films per bulk request")
+	flag.BoolVar(&keepOld, "keep-old", false, "Keep the previous index after swapping the alias")
+	flag.Parse()
+
+	if batch < 1 {
+		log.Fatalf("Invalid batch size: %d", batch)
+	}
+
+	if err := run(env, config, batch, keepOld); err != nil {
+		log.Fatalf("Couldn't reindex: %s", err)
+	}
+}
+
+func run(env, config string, batch int, keepOld bool) error {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return fmt.Errorf("zap.NewProduction %w", err)
+	}
+	defer logger.Sync()
+
+	if err := envvar.Load(env); err != nil {
+		return fmt.Errorf("envvar.Load %w", err)
+	}
+
+	secrets, err := envvar.NewSecretsFromEnv()
+	if err != nil {
+		return fmt.Errorf("envvar.NewSecretsFromEnv %w", err)
+	}
+
+	conf, err := envvar.LoadConfig(envvar.New(secrets), config)
+	if err != nil {
+		return fmt.Errorf("envvar.LoadConfig %w", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), conf.Tracing)
+	if err != nil {
+		return fmt.Errorf("tracing.Setup %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Flushing traces failed", zap.Error(err))
+		}
+	}()
+
+	db, err := postgresql.Connect(conf.Database)
+	if err != nil {
+		return fmt.Errorf("postgresql.Connect %w", err)
+	}
+	defer db.Close()
+
+	es, err := elasticsearch.NewClient(conf.Search)
+	if err != nil {
+		return fmt.Errorf("elasticsearch.NewClient %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+	defer stop()
+
+	search := elasticsearch.NewFilmSearchRepo(es, conf.Search.FilmsIndex)
+
+	// NOTE: Unlike the server, there is nothing to do while Elasticsearch is down.
+	if err := search.Ping(ctx); err != nil {
+		return fmt.Errorf("search.Ping %w", err)
+	}
+
+	svc := service.NewReindexService(postgresql.NewFilm(db), postgresql.NewOutbox(db), search, logger)
+
+	created, count, err := svc.Reindex(ctx, batch, keepOld)
+	if err != nil {
+		return fmt.Errorf("reindex %w", err)
+	}
+
+	logger.Info("Reindex completed", zap.String("index", created), zap.Int("films", count))
+
+	return nil
+}
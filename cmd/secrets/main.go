@@ -0,0 +1,96 @@
+// Command secrets manages the encrypted local secrets file, see envvar.LocalSecrets. The file is
+// named by SECRETS_FILE and encrypted with the key in SECRETS_KEY, or given with -file and -key.
+//
+//	secrets keygen             prints a new key
+//	secrets set <name>         sets a secret to the value read from standard input
+//	secrets delete <name>      deletes a secret
+//	secrets list               lists the names of the secrets
+//
+// Settings then reference the secrets, e.g. DB_URL_SECURE=local:db_url.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"filmoteka/internal/envvar"
+)
+
+func main() {
+	var (
+		filename string
+		key      string
+	)
+
+	flag.StringVar(&filename, "file", os.Getenv(envvar.SecretsFileEnv), "Encrypted secrets filename")
+	flag.StringVar(&key, "key", os.Getenv(envvar.SecretsKeyEnv), "Base64 encoded key of the secrets file")
+	flag.Parse()
+
+	if err := run(filename, key, flag.Args()); err != nil {
+		log.Fatalf("secrets: %s", err)
+	}
+}
+
+func run(filename, encodedKey string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command: keygen, set, delete or list")
+	}
+
+	if args[0] == "keygen" {
+		key, err := envvar.NewSecretsKey()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(key)
+
+		return nil
+	}
+
+	if filename == "" {
+		return fmt.Errorf("missing secrets file, set %s or -file", envvar.SecretsFileEnv)
+	}
+
+	key, err := envvar.DecodeSecretsKey(encodedKey)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := envvar.OpenLocalSecrets(filename, key)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "set" && len(args) == 2:
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("reading value: %w", err)
+		}
+
+		secrets.Set(args[1], strings.TrimRight(value, "\r\n"))
+
+		return secrets.Save()
+	case args[0] == "delete" && len(args) == 2:
+		secrets.Delete(args[1])
+
+		return secrets.Save()
+	case args[0] == "list" && len(args) == 1:
+		names := secrets.Names()
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Println(name)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("invalid command %q", strings.Join(args, " "))
+	}
+}
@@ -1,3 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TYPE g AS ENUM ('M', 'F');
 
 CREATE TABLE IF NOT EXISTS public.films (
@@ -5,14 +7,50 @@ CREATE TABLE IF NOT EXISTS public.films (
 	name varchar(150) NOT NULL,
 	description varchar(500),
 	release_year smallint NOT NULL,
-    rating float 
+    rating float,
+	version integer NOT NULL DEFAULT 1,
+	search tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', name), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B')
+	) STORED
 );
 
 CREATE TABLE IF NOT EXISTS public.actors (
 	id SERIAL PRIMARY KEY,
 	name varchar(100) NOT NULL,
 	gender g NOT NULL,
-	birth_date date NOT NULL
+	birth_date date NOT NULL,
+	version integer NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX ON public.films(name, release_year);
+CREATE INDEX films_search_idx ON public.films USING GIN (search);
+CREATE INDEX films_name_trgm_idx ON public.films USING GIN (name gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS public.film_actors (
+	film_id integer NOT NULL REFERENCES public.films(id) ON DELETE CASCADE,
+	actor_id integer NOT NULL REFERENCES public.actors(id) ON DELETE CASCADE,
+	character_name varchar(150) NOT NULL,
+	billing_order smallint NOT NULL DEFAULT 0,
+	PRIMARY KEY (film_id, actor_id)
+);
+
+CREATE INDEX ON public.film_actors(actor_id);
+
+CREATE TABLE IF NOT EXISTS public.search_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	entity varchar(20) NOT NULL,
+	entity_id integer NOT NULL,
+	operation varchar(10) NOT NULL,
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	txid bigint NOT NULL DEFAULT txid_current(),
+	delivered_at timestamptz,
+	dead_at timestamptz
 );
 
-CREATE UNIQUE INDEX ON public.films(name, release_year);
\ No newline at end of file
+CREATE INDEX ON public.search_outbox(next_attempt_at);
+CREATE INDEX ON public.search_outbox(txid);
+CREATE INDEX ON public.search_outbox(delivered_at);
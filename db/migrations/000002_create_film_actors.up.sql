@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS public.film_actors (
+	film_id integer NOT NULL REFERENCES public.films(id) ON DELETE CASCADE,
+	actor_id integer NOT NULL REFERENCES public.actors(id) ON DELETE CASCADE,
+	character_name varchar(150) NOT NULL,
+	billing_order smallint NOT NULL DEFAULT 0,
+	PRIMARY KEY (film_id, actor_id)
+);
+
+CREATE INDEX ON public.film_actors(actor_id);
@@ -0,0 +1,2 @@
+ALTER TABLE films DROP COLUMN IF EXISTS version;
+ALTER TABLE actors DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+ALTER TABLE public.films ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE public.actors ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
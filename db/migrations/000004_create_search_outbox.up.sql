@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.search_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	entity varchar(20) NOT NULL,
+	entity_id integer NOT NULL,
+	operation varchar(10) NOT NULL,
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON public.search_outbox(next_attempt_at);
@@ -0,0 +1 @@
+DELETE FROM public.search_outbox WHERE entity = 'actor';
@@ -0,0 +1,3 @@
+-- Existing actors are delivered to the new actors search index by the search relay.
+INSERT INTO public.search_outbox (entity, entity_id, operation)
+SELECT 'actor', id, 'index' FROM public.actors;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS films_name_trgm_idx;
+ALTER TABLE films DROP COLUMN IF EXISTS search;
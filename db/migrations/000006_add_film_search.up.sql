@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE public.films ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', name), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS films_search_idx ON public.films USING GIN (search);
+CREATE INDEX IF NOT EXISTS films_name_trgm_idx ON public.films USING GIN (name gin_trgm_ops);
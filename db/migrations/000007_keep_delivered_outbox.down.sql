@@ -0,0 +1,5 @@
+DELETE FROM public.search_outbox WHERE delivered_at IS NOT NULL;
+DROP INDEX IF EXISTS search_outbox_delivered_at_idx;
+DROP INDEX IF EXISTS search_outbox_txid_idx;
+ALTER TABLE public.search_outbox DROP COLUMN IF EXISTS delivered_at;
+ALTER TABLE public.search_outbox DROP COLUMN IF EXISTS txid;
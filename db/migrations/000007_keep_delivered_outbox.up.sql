@@ -0,0 +1,7 @@
+-- Delivered events are kept for a while, so that a rebuild of the search index can replay the
+-- changes made while it runs. txid orders the events by the transaction writing them.
+ALTER TABLE public.search_outbox ADD COLUMN IF NOT EXISTS txid bigint NOT NULL DEFAULT txid_current();
+ALTER TABLE public.search_outbox ADD COLUMN IF NOT EXISTS delivered_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS search_outbox_txid_idx ON public.search_outbox(txid);
+CREATE INDEX IF NOT EXISTS search_outbox_delivered_at_idx ON public.search_outbox(delivered_at);
@@ -0,0 +1 @@
+ALTER TABLE public.search_outbox DROP COLUMN IF EXISTS dead_at;
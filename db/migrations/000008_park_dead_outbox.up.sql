@@ -0,0 +1,2 @@
+-- Events failing every delivery attempt are parked, they stay for inspection.
+ALTER TABLE public.search_outbox ADD COLUMN IF NOT EXISTS dead_at timestamptz;
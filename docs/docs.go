@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/actors": {
             "get": {
-                "description": "get all actors",
+                "description": "get a page of actors",
                 "consumes": [
                     "application/json"
                 ],
@@ -27,14 +27,63 @@ const docTemplate = `{
                 "tags": [
                     "Actors"
                 ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, 1 to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of actors to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page, replaces offset",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields, `+"`"+`-`+"`"+` for descending, e.g. -birth_date,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "M or F",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest birth date, 2006-01-02 format",
+                        "name": "born_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest birth date, 2006-01-02 format",
+                        "name": "born_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the cached page",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Actor"
-                            }
+                            "$ref": "#/definitions/restapi.ListActorsResponse"
                         }
                     },
                     "400": {
@@ -43,12 +92,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal.Error"
                         }
                     },
-                    "404": {
-                        "description": "Resource not found",
-                        "schema": {
-                            "$ref": "#/definitions/internal.Error"
-                        }
-                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -101,6 +144,84 @@ const docTemplate = `{
                 }
             }
         },
+        "/actors/search": {
+            "get": {
+                "description": "search actors by name, typos are tolerated",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actors"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name to search for, the more terms match the higher the score",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "AUTO",
+                        "description": "Typos tolerated in each term of q: 0, 1, 2 or AUTO",
+                        "name": "fuzziness",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "M or F",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest birth date, 2006-01-02 format",
+                        "name": "born_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest birth date, 2006-01-02 format",
+                        "name": "born_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, 1 to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.SearchActorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, fields holds the invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
         "/actors/{id}": {
             "get": {
                 "description": "get one actors by id",
@@ -117,6 +238,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the cached actor",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -126,6 +253,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Actor"
                         }
                     },
+                    "304": {
+                        "description": "not modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "400": {
                         "description": "Bad request",
                         "schema": {
@@ -165,6 +298,12 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed, without it the change is unconditional",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "input data",
                         "name": "json",
@@ -179,7 +318,13 @@ const docTemplate = `{
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "$ref": "#/definitions/models.Actor"
+                            "type": "string"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the new version"
+                            }
                         }
                     },
                     "400": {
@@ -194,6 +339,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal.Error"
                         }
                     },
+                    "412": {
+                        "description": "Precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -217,6 +368,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed, without it the change is unconditional",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -238,6 +395,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal.Error"
                         }
                     },
+                    "412": {
+                        "description": "Precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -245,81 +408,1225 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "internal.Error": {
-            "type": "object"
-        },
-        "models.Actor": {
-            "type": "object",
-            "required": [
-                "birth_date",
-                "gender",
-                "name"
-            ],
-            "properties": {
-                "birth_date": {
-                    "description": "Birth date in 2006-01-02 format",
-                    "type": "string",
-                    "example": "1963-06-09"
-                },
-                "gender": {
-                    "description": "Gender, \"M\" or \"F\"",
-                    "type": "string",
-                    "example": "M"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "description": "Actors name",
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 3,
-                    "example": "Johnny Depp"
+            },
+            "patch": {
+                "description": "Partially update actor by id with a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902)",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actors"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actor ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed, without it the change is unconditional",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "patch document",
+                        "name": "json",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.Actor"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the new version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
                 }
             }
         },
-        "models.CreateActor": {
-            "type": "object",
-            "required": [
-                "birth_date",
-                "gender",
-                "name"
-            ],
-            "properties": {
-                "birth_date": {
-                    "description": "Birth date in 2006-01-02 format",
-                    "type": "string",
-                    "example": "1963-06-09"
-                },
-                "gender": {
-                    "description": "Gender, \"M\" or \"F\"",
-                    "type": "string",
-                    "example": "M"
-                },
-                "name": {
-                    "description": "Actors name",
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 3,
-                    "example": "Johnny Depp"
+        "/actors/{id}/films": {
+            "get": {
+                "description": "get the films an actor played in",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cast"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actor ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Role"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
                 }
             }
         },
-        "models.UpdateActor": {
-            "type": "object",
-            "required": [
-                "birth_date",
-                "gender",
-                "name"
-            ],
-            "properties": {
-                "birth_date": {
-                    "description": "Birth date in 2006-01-02 format",
-                    "type": "string",
+        "/films/search": {
+            "get": {
+                "description": "search films, text criteria are matched against the name and the description",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Films"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text to search for, the more terms match the higher the score",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Terms every film found contains",
+                        "name": "must",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Optional terms, films containing them score higher",
+                        "name": "should",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Phrases every film found contains verbatim",
+                        "name": "phrase",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "AUTO",
+                        "description": "Typos tolerated in each term of q, must and should: 0, 1, 2 or AUTO",
+                        "name": "fuzziness",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earliest release year, doesn't affect the score",
+                        "name": "year_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest release year, doesn't affect the score",
+                        "name": "year_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum rating, doesn't affect the score",
+                        "name": "rating_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum rating, doesn't affect the score",
+                        "name": "rating_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Selected release decades, e.g. 1990",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Selected rating buckets: 0-5, 5-6, 6-7, 7-8, 8-9 or 9-10",
+                        "name": "rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Field weight, e.g. name:3",
+                        "name": "boost",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated score, rating or release_year, `+"`"+`-`+"`"+` for descending, e.g. -rating,-score",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, 1 to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page, replaces page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.SearchFilmsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, fields holds the invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "search films with a JSON query, for queries too complex for query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Films"
+                ],
+                "parameters": [
+                    {
+                        "description": "search query",
+                        "name": "json",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchFilms"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.SearchFilmsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, fields holds the invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/films/{id}/cast": {
+            "get": {
+                "description": "get the cast of a film",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cast"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Film ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CastMember"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add an actor to the cast of a film",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cast"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Film ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "input data",
+                        "name": "json",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateCast"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.CastMember"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Film or actor not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Actor already in cast",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/films/{id}/cast/{actor_id}": {
+            "put": {
+                "description": "update the role of an actor in a film",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cast"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Film ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Actor ID",
+                        "name": "actor_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "input data",
+                        "name": "json",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateCast"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove an actor from the cast of a film",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cast"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Film ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Actor ID",
+                        "name": "actor_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Resource not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/films/{id}/similar": {
+            "get": {
+                "description": "get the films most similar to a film by name and description, most similar first, the film itself excluded",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Films"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Film ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of films, 1 to 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.SimilarFilmsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, fields holds the invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "check the process is alive",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "check the service can serve requests: Postgres is critical, Elasticsearch only degrades the service as searches fall back to Postgres",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok or degraded",
+                        "schema": {
+                            "$ref": "#/definitions/models.Health"
+                        }
+                    },
+                    "503": {
+                        "description": "failing or shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/models.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/breaker": {
+            "get": {
+                "description": "get the state of the circuit breaker guarding the search index, searches are served by Postgres while it is open",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.BreakerStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/outbox": {
+            "get": {
+                "description": "get the lag and the failures of the delivery of changes to the search index",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggest": {
+            "get": {
+                "description": "suggest films and actors while their name is being typed, best matches first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text typed so far, every word of it must start a word of the name",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of films and of actors, 1 to 20",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.Suggestions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, fields holds the invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal.Error": {
+            "type": "object"
+        },
+        "models.Actor": {
+            "type": "object",
+            "required": [
+                "birth_date",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "birth_date": {
+                    "description": "Birth date in 2006-01-02 format",
+                    "type": "string",
+                    "example": "1963-06-09"
+                },
+                "gender": {
+                    "description": "Gender, \"M\" or \"F\"",
+                    "type": "string",
+                    "example": "M"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Actors name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Johnny Depp"
+                }
+            }
+        },
+        "models.ActorHit": {
+            "type": "object",
+            "required": [
+                "birth_date",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "birth_date": {
+                    "description": "Birth date in 2006-01-02 format",
+                    "type": "string",
+                    "example": "1963-06-09"
+                },
+                "gender": {
+                    "description": "Gender, \"M\" or \"F\"",
+                    "type": "string",
+                    "example": "M"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Actors name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Johnny Depp"
+                },
+                "score": {
+                    "description": "Relevance of the actor to the search, higher is better",
+                    "type": "number"
+                }
+            }
+        },
+        "models.BreakerStats": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Failures is the number of consecutive failed calls.",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name of the backend guarded.",
+                    "type": "string"
+                },
+                "opened_at": {
+                    "description": "OpenedAt is when the breaker last opened, if it's not closed.",
+                    "type": "string"
+                },
+                "opens": {
+                    "description": "Opens is the number of times the breaker opened since the start.",
+                    "type": "integer"
+                },
+                "rejected": {
+                    "description": "Rejected is the number of calls failed fast since the start.",
+                    "type": "integer"
+                },
+                "retries": {
+                    "description": "Retries is the number of calls retried since the start.",
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CastMember": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "integer"
+                },
+                "billing_order": {
+                    "description": "Position in the credits, lower goes first",
+                    "type": "integer",
+                    "example": 1
+                },
+                "character_name": {
+                    "description": "Character played in the film",
+                    "type": "string",
+                    "example": "Jack Sparrow"
+                },
+                "name": {
+                    "description": "Actors name",
+                    "type": "string",
+                    "example": "Johnny Depp"
+                }
+            }
+        },
+        "models.CreateActor": {
+            "type": "object",
+            "required": [
+                "birth_date",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "birth_date": {
+                    "description": "Birth date in 2006-01-02 format",
+                    "type": "string",
+                    "example": "1963-06-09"
+                },
+                "gender": {
+                    "description": "Gender, \"M\" or \"F\"",
+                    "type": "string",
+                    "example": "M"
+                },
+                "name": {
+                    "description": "Actors name",
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "Johnny Depp"
+                }
+            }
+        },
+        "models.CreateCast": {
+            "type": "object",
+            "required": [
+                "actor_id",
+                "character_name"
+            ],
+            "properties": {
+                "actor_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "billing_order": {
+                    "description": "Position in the credits, lower goes first",
+                    "type": "integer",
+                    "maximum": 32767,
+                    "example": 1
+                },
+                "character_name": {
+                    "description": "Character played in the film",
+                    "type": "string",
+                    "maxLength": 150,
+                    "minLength": 1,
+                    "example": "Jack Sparrow"
+                }
+            }
+        },
+        "models.FacetBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "selected": {
+                    "description": "Selected is true when the search is filtered by the value",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.FieldErrors": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.FilmHit": {
+            "type": "object",
+            "required": [
+                "description",
+                "name",
+                "rating",
+                "release_year"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "minLength": 5
+                },
+                "highlights": {
+                    "description": "Highlights hold the fragments of the name and of the description matching the search,\nHTML escaped with the matches wrapped in \u003cem\u003e tags",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 150,
+                    "minLength": 2
+                },
+                "rating": {
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "release_year": {
+                    "type": "integer",
+                    "maximum": 2030,
+                    "minimum": 1900
+                },
+                "score": {
+                    "description": "Relevance of the film to the search, higher is better",
+                    "type": "number"
+                }
+            }
+        },
+        "models.Health": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.HealthCheck"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "description": "Critical dependencies failing make the service unready, others degrade it",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "description": "Status is either ok or failing",
+                    "type": "string"
+                }
+            }
+        },
+        "models.RelayStats": {
+            "type": "object",
+            "properties": {
+                "dead": {
+                    "description": "Dead is the number of events parked after failing every attempt, they are not delivered\nanymore.",
+                    "type": "integer"
+                },
+                "delivered": {
+                    "description": "Delivered is the number of events delivered since the relay started.",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed is the number of failed deliveries since the relay started.",
+                    "type": "integer"
+                },
+                "lag_seconds": {
+                    "description": "LagSeconds is the age of the oldest pending event.",
+                    "type": "number"
+                },
+                "pending": {
+                    "description": "Pending is the number of events not delivered yet.",
+                    "type": "integer"
+                },
+                "retrying": {
+                    "description": "Retrying is the number of pending events that failed at least once.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Role": {
+            "type": "object",
+            "properties": {
+                "billing_order": {
+                    "description": "Position in the credits, lower goes first",
+                    "type": "integer",
+                    "example": 1
+                },
+                "character_name": {
+                    "description": "Character played in the film",
+                    "type": "string",
+                    "example": "Jack Sparrow"
+                },
+                "film_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchFilms": {
+            "type": "object",
+            "properties": {
+                "boosts": {
+                    "description": "Weight of the matches in each field, name 2 and description 1 by default",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "decades": {
+                    "description": "Facet selections: release decades, e.g. 1990, and rating buckets, see RatingRanges. Values\nof a facet are alternatives, facets all apply. They don't affect the score",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "fuzziness": {
+                    "description": "Fuzziness is the number of typos tolerated in each term of q, must and should: 0, 1, 2 or\nAUTO, which depends on the length of the term; AUTO by default. Phrases are exact",
+                    "type": "string",
+                    "enum": [
+                        "AUTO",
+                        "0",
+                        "1",
+                        "2"
+                    ],
+                    "example": "AUTO"
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "must": {
+                    "description": "Terms every film found contains",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "page": {
+                    "description": "Page number, starting at 1",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "phrases": {
+                    "description": "Phrases every film found contains verbatim",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "black pearl"
+                    ]
+                },
+                "q": {
+                    "description": "Query is matched term by term, the more terms match the higher the score",
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "pirates caribbean"
+                },
+                "rating_max": {
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "rating_min": {
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "ratings": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "search_after": {
+                    "description": "SearchAfter holds the sort values of the last film of the previous page, it replaces page\nand goes past the deepest page",
+                    "type": "array",
+                    "items": {}
+                },
+                "should": {
+                    "description": "Optional terms, films containing them score higher",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sort": {
+                    "description": "Sort by score, rating or release_year, by descending score by default",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SortField"
+                    }
+                },
+                "year_from": {
+                    "description": "Release year and rating ranges only filter, they don't affect the score",
+                    "type": "integer",
+                    "maximum": 2030,
+                    "minimum": 1900
+                },
+                "year_to": {
+                    "type": "integer",
+                    "maximum": 2030,
+                    "minimum": 1900
+                }
+            }
+        },
+        "models.SortField": {
+            "type": "object",
+            "properties": {
+                "desc": {
+                    "type": "boolean"
+                },
+                "field": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Suggestion": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Suggestions": {
+            "type": "object",
+            "properties": {
+                "actors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Suggestion"
+                    }
+                },
+                "films": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Suggestion"
+                    }
+                }
+            }
+        },
+        "models.UpdateActor": {
+            "type": "object",
+            "required": [
+                "birth_date",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "birth_date": {
+                    "description": "Birth date in 2006-01-02 format",
+                    "type": "string",
                     "example": "1963-06-09"
                 },
                 "gender": {
@@ -335,6 +1642,144 @@ const docTemplate = `{
                     "example": "Johnny Depp"
                 }
             }
+        },
+        "models.UpdateCast": {
+            "type": "object",
+            "required": [
+                "character_name"
+            ],
+            "properties": {
+                "billing_order": {
+                    "description": "Position in the credits, lower goes first",
+                    "type": "integer",
+                    "maximum": 32767,
+                    "example": 1
+                },
+                "character_name": {
+                    "description": "Character played in the film",
+                    "type": "string",
+                    "maxLength": 150,
+                    "minLength": 1,
+                    "example": "Jack Sparrow"
+                }
+            }
+        },
+        "restapi.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields holds the reason each invalid field or parameter is invalid, if known.",
+                    "$ref": "#/definitions/models.FieldErrors"
+                }
+            }
+        },
+        "restapi.ListActorsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Actor"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "restapi.SearchActorsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ActorHit"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "restapi.SearchFilmsResponse": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string"
+                },
+                "facets": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.FacetBucket"
+                        }
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FilmHit"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "search_after": {
+                    "type": "array",
+                    "items": {}
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "restapi.SimilarFilmsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FilmHit"
+                    }
+                }
+            }
         }
     }
 }`
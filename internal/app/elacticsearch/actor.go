@@ -0,0 +1,185 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	esv7 "github.com/elastic/go-elasticsearch/v7"
+	esv7api "github.com/elastic/go-elasticsearch/v7/esapi"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// ActorSearchRepo represents the repository used for searching Actor records.
+type ActorSearchRepo struct {
+	aliasedIndex
+}
+
+type indexedActor struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Gender    string `json:"gender"`
+	BirthDate string `json:"birth_date"`
+}
+
+func newIndexedActor(actor models.Actor) indexedActor {
+	return indexedActor{
+		Id:        actor.Id,
+		Name:      actor.Name,
+		Gender:    actor.Gender,
+		BirthDate: actor.BirthDate,
+	}
+}
+
+// NewActorSearchRepo instantiates the ActorSearchRepo repository. index is the alias of the
+// actors index, see Bootstrap.
+func NewActorSearchRepo(client *esv7.Client, index string) *ActorSearchRepo {
+	return &ActorSearchRepo{
+		aliasedIndex: aliasedIndex{
+			client:     client,
+			index:      index,
+			definition: actorsIndex,
+			version:    actorsMappingVersion,
+		},
+	}
+}
+
+// Index creates or updates an actor in the index.
+func (a *ActorSearchRepo) Index(ctx context.Context, actor models.Actor) error {
+	ctx, span := tracer.Start(ctx, "ActorSearchRepo.Index")
+	defer span.End()
+
+	return a.put(ctx, strconv.Itoa(actor.Id), newIndexedActor(actor))
+}
+
+// Delete removes an actor from the index, removing a missing actor succeeds.
+func (a *ActorSearchRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "ActorSearchRepo.Delete")
+	defer span.End()
+
+	return a.remove(ctx, id)
+}
+
+// Suggest returns up to limit actors whose name has words starting with the words of q, best
+// matches first.
+func (a *ActorSearchRepo) Suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error) {
+	ctx, span := tracer.Start(ctx, "ActorSearchRepo.Suggest")
+	defer span.End()
+
+	return a.suggest(ctx, q, limit)
+}
+
+// Search returns a page of the actors matching p, by descending score then name, and the total
+// number of actors found.
+func (a *ActorSearchRepo) Search(ctx context.Context, p m.SearchActors) (models.ActorSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "ActorSearchRepo.Search")
+	defer span.End()
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(actorSearchBody(p)); err != nil {
+		return models.ActorSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.SearchRequest{
+		Index: []string{a.index},
+		Body:  &buf,
+	}
+
+	resp, err := req.Do(ctx, a.client)
+	if err != nil {
+		return models.ActorSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "SearchRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return models.ActorSearchResult{}, responseError("SearchRequest.Do", resp.StatusCode)
+	}
+
+	var hits struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source indexedActor `json:"_source"`
+				Score  float64      `json:"_score"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil {
+		return models.ActorSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	res := models.ActorSearchResult{
+		Total: hits.Hits.Total.Value,
+		Hits:  make([]models.ActorHit, len(hits.Hits.Hits)),
+	}
+
+	for i, hit := range hits.Hits.Hits {
+		res.Hits[i].Id = hit.Source.Id
+		res.Hits[i].Name = hit.Source.Name
+		res.Hits[i].Gender = hit.Source.Gender
+		res.Hits[i].BirthDate = hit.Source.BirthDate
+		res.Hits[i].Score = hit.Score
+	}
+
+	return res, nil
+}
+
+// actorSearchBody returns the body of the search request for p. The name is matched with
+// typos, exact and prefix matches score higher; gender and birth dates only filter.
+func actorSearchBody(p m.SearchActors) map[string]interface{} {
+	fuzziness := p.Fuzziness
+	if fuzziness == "" {
+		fuzziness = "AUTO"
+	}
+
+	boolQuery := map[string]interface{}{}
+
+	if p.Query != "" {
+		boolQuery["must"] = map[string]interface{}{
+			"match": map[string]interface{}{"name": map[string]interface{}{"query": p.Query, "fuzziness": fuzziness}},
+		}
+		boolQuery["should"] = []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"name": p.Query}},
+			map[string]interface{}{"match": map[string]interface{}{"name.prefix": p.Query}},
+		}
+	}
+
+	filter := make([]interface{}, 0, 2)
+	if p.Gender != "" {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"gender": p.Gender}})
+	}
+	if p.BornFrom != "" || p.BornTo != "" {
+		r := map[string]interface{}{}
+		if p.BornFrom != "" {
+			r["gte"] = p.BornFrom
+		}
+		if p.BornTo != "" {
+			r["lte"] = p.BornTo
+		}
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"birth_date": r}})
+	}
+	if len(filter) > 0 {
+		boolQuery["filter"] = filter
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{"bool": boolQuery},
+		"sort": []interface{}{
+			map[string]interface{}{"_score": "desc"},
+			map[string]interface{}{"name.keyword": "asc"},
+			map[string]interface{}{"id": "asc"},
+		},
+		"size":             p.Limit,
+		"from":             (p.Page - 1) * p.Limit,
+		"track_total_hits": true,
+		"track_scores":     true,
+	}
+}
@@ -0,0 +1,153 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+const (
+	facetDecade = models.FacetDecade
+	facetRating = models.FacetRating
+
+	maxRating = 10
+)
+
+// facets are the names of the facets of film searches. Categorical fields get a `terms`
+// aggregation in facetAggs and their selection a `terms` filter in facetFilters.
+var facets = []string{facetDecade, facetRating}
+
+// facetFilters returns the filters of the facet selections of p by facet, facets without
+// selection are left out.
+func facetFilters(p m.SearchFilms) map[string]interface{} {
+	filters := map[string]interface{}{}
+
+	if len(p.Decades) > 0 {
+		should := make([]interface{}, len(p.Decades))
+		for i, d := range p.Decades {
+			should[i] = map[string]interface{}{
+				"range": map[string]interface{}{"release_year": map[string]interface{}{"gte": d, "lte": d + 9}},
+			}
+		}
+		filters[facetDecade] = map[string]interface{}{"bool": map[string]interface{}{"should": should}}
+	}
+
+	if len(p.Ratings) > 0 {
+		should := make([]interface{}, 0, len(p.Ratings))
+		for _, r := range m.RatingRanges {
+			if slices.Contains(p.Ratings, r.Key) {
+				should = append(should, map[string]interface{}{
+					"range": map[string]interface{}{"rating": ratingRange(r, "gte", "lt")},
+				})
+			}
+		}
+		filters[facetRating] = map[string]interface{}{"bool": map[string]interface{}{"should": should}}
+	}
+
+	return filters
+}
+
+// facetAggs returns the aggregations counting the films by value of every facet. Each one is
+// filtered by the selections of the other facets only, while hits are filtered by all of them
+// in `post_filter`.
+func facetAggs(filters map[string]interface{}) map[string]interface{} {
+	ranges := make([]interface{}, len(m.RatingRanges))
+	for i, r := range m.RatingRanges {
+		bucket := ratingRange(r, "from", "to")
+		bucket["key"] = r.Key
+		ranges[i] = bucket
+	}
+
+	values := map[string]interface{}{
+		facetDecade: map[string]interface{}{
+			"histogram": map[string]interface{}{
+				"field":         "release_year",
+				"interval":      10,
+				"min_doc_count": 1,
+			},
+		},
+		facetRating: map[string]interface{}{
+			"range": map[string]interface{}{
+				"field":  "rating",
+				"ranges": ranges,
+			},
+		},
+	}
+
+	aggs := make(map[string]interface{}, len(facets))
+	for _, name := range facets {
+		aggs[name] = map[string]interface{}{
+			"filter": map[string]interface{}{"bool": map[string]interface{}{"filter": otherFilters(filters, name)}},
+			"aggs":   map[string]interface{}{"values": values[name]},
+		}
+	}
+
+	return aggs
+}
+
+// postFilter returns the filter of all the facet selections, nil when nothing is selected.
+func postFilter(filters map[string]interface{}) interface{} {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": otherFilters(filters, "")}}
+}
+
+// otherFilters returns the filters of every facet but the excluded one, in facets order so that
+// identical searches send identical requests and hit the request cache.
+func otherFilters(filters map[string]interface{}, excluded string) []interface{} {
+	res := make([]interface{}, 0, len(filters))
+	for _, name := range facets {
+		if f, ok := filters[name]; ok && name != excluded {
+			res = append(res, f)
+		}
+	}
+
+	return res
+}
+
+// ratingRange returns the bounds of a rating bucket with the given keys, the highest bucket
+// has no upper bound so that it includes the highest rating.
+func ratingRange(r m.RatingRange, from, to string) map[string]interface{} {
+	res := map[string]interface{}{from: r.From}
+	if r.To < maxRating {
+		res[to] = r.To
+	}
+
+	return res
+}
+
+// facetBuckets converts the aggregation buckets of a facet, marking the selected values.
+func facetBuckets(buckets []facetAggBucket, selected func(key string) bool) []models.FacetBucket {
+	res := make([]models.FacetBucket, len(buckets))
+	for i, b := range buckets {
+		var key string
+		switch k := b.Key.(type) {
+		case string:
+			key = k
+		case json.Number:
+			// NOTE: Histogram keys are floats, e.g. 1990.0.
+			f, _ := k.Float64()
+			key = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+
+		res[i] = models.FacetBucket{
+			Key:      key,
+			Count:    b.DocCount,
+			Selected: selected(key),
+		}
+	}
+
+	return res
+}
+
+// facetAggBucket is a bucket of the response of facetAggs.
+type facetAggBucket struct {
+	// Key is a json.Number, or a string for ranges.
+	Key      interface{} `json:"key"`
+	DocCount int         `json:"doc_count"`
+}
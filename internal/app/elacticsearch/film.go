@@ -4,216 +4,390 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
-	"io"
+	"slices"
 	"strconv"
 
 	esv7 "github.com/elastic/go-elasticsearch/v7"
 	esv7api "github.com/elastic/go-elasticsearch/v7/esapi"
-	"go.opentelemetry.io/otel/trace"
 
 	"filmoteka/internal"
 	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
 )
 
 // Film represents the repository used for interacting with Film records.
 type FilmSearchRepo struct {
-	client *esv7.Client
-	index  string
+	aliasedIndex
 }
 
 type indexedFilm struct {
-	Id          int     `json:"id"`
-	Name        string  `json:"name" validate:"required,min=2,max=150"`
-	Description string  `json:"description" validate:"required,min=5,max=500"`
-	ReleaseYear uint16  `json:"release_year" validate:"required,gte=1900,lte=2030"`
-	Rating      float32 `json:"rating" validate:"required,gte=0,lte=10"`
+	Id          int      `json:"id"`
+	Name        string   `json:"name" validate:"required,min=2,max=150"`
+	Description string   `json:"description" validate:"required,min=5,max=500"`
+	ReleaseYear uint16   `json:"release_year" validate:"required,gte=1900,lte=2030"`
+	Rating      float32  `json:"rating" validate:"required,gte=0,lte=10"`
+	Actors      []string `json:"actors,omitempty"`
 }
 
-// NewFilmSerchRepo instantiates the FilmSearchRepo repository.
-func NewFilmSearchRepo(client *esv7.Client) *FilmSearchRepo {
+func newIndexedFilm(film models.Film) indexedFilm {
+	return indexedFilm{
+		Id:          film.Id,
+		Name:        film.Name,
+		Description: film.Description,
+		ReleaseYear: film.ReleaseYear,
+		Rating:      film.Rating,
+		Actors:      film.Actors,
+	}
+}
+
+// NewFilmSerchRepo instantiates the FilmSearchRepo repository. index is the alias of the films
+// index, see Bootstrap.
+func NewFilmSearchRepo(client *esv7.Client, index string) *FilmSearchRepo {
 	return &FilmSearchRepo{
-		client: client,
-		index:  "films",
+		aliasedIndex: aliasedIndex{
+			client:     client,
+			index:      index,
+			definition: filmsIndex,
+			version:    filmsMappingVersion,
+		},
 	}
 }
 
 // Index creates or updates a film in an index.
 func (f *FilmSearchRepo) Index(ctx context.Context, film models.Film) error {
-	span := trace.SpanFromContext(ctx)
+	ctx, span := tracer.Start(ctx, "FilmSearchRepo.Index")
 	defer span.End()
 
-	body := indexedFilm{
-		Id:          film.Id,
-		Name:        film.Name,
-		Description: film.Description,
-		ReleaseYear: film.ReleaseYear,
-		Rating:      film.Rating,
-	}
+	return f.put(ctx, strconv.Itoa(film.Id), newIndexedFilm(film))
+}
+
+// Delete removes a film from the index, removing a missing film succeeds.
+func (t *FilmSearchRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "FilmSearchRepo.Delete")
+	defer span.End()
+
+	return t.remove(ctx, id)
+}
+
+// Suggest returns up to limit films whose name has words starting with the words of q, best
+// matches first.
+func (f *FilmSearchRepo) Suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error) {
+	ctx, span := tracer.Start(ctx, "FilmSearchRepo.Suggest")
+	defer span.End()
+
+	return f.suggest(ctx, q, limit)
+}
+
+// similarFields are the fields films are compared on by Similar, add the genres once they are
+// indexed.
+var similarFields = []string{"name", "description", "actors"}
+
+// Similar returns up to limit films resembling the film with the id, most similar first, using
+// `more_like_this` over similarFields. The film itself is left out; a film missing from the
+// index has no similar films.
+func (f *FilmSearchRepo) Similar(ctx context.Context, id string, limit int) ([]models.FilmHit, error) {
+	ctx, span := tracer.Start(ctx, "FilmSearchRepo.Similar")
+	defer span.End()
 
 	var buf bytes.Buffer
 
+	body := map[string]interface{}{
+		"size": limit,
+		"query": map[string]interface{}{
+			"more_like_this": map[string]interface{}{
+				"fields": similarFields,
+				"like":   []interface{}{map[string]interface{}{"_id": id}},
+				// NOTE: Films are short texts, every term counts.
+				"min_term_freq": 1,
+				"min_doc_freq":  1,
+				"include":       false,
+			},
+		},
+	}
 	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
 	}
 
-	req := esv7api.IndexRequest{
-		Index:      f.index,
-		Body:       &buf,
-		DocumentID: strconv.Itoa(film.Id),
-		Refresh:    "true",
+	req := esv7api.SearchRequest{
+		Index: []string{f.index},
+		Body:  &buf,
 	}
 
 	resp, err := req.Do(ctx, f.client)
 	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndexRequest.Do")
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "SearchRequest.Do")
 	}
 	defer resp.Body.Close()
 
 	if resp.IsError() {
-		return internal.NewErrorf(internal.ErrorCodeUnknown, "IndexRequest.Do %d", resp.StatusCode)
+		return nil, responseError("SearchRequest.Do", resp.StatusCode)
 	}
 
-	io.Copy(io.Discard, resp.Body)
+	var hits struct {
+		Hits struct {
+			Hits []struct {
+				Source indexedFilm `json:"_source"`
+				Score  float64     `json:"_score"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	res := make([]models.FilmHit, len(hits.Hits.Hits))
+	for i, hit := range hits.Hits.Hits {
+		res[i].Id = hit.Source.Id
+		res[i].Name = hit.Source.Name
+		res[i].Description = hit.Source.Description
+		res[i].ReleaseYear = hit.Source.ReleaseYear
+		res[i].Rating = hit.Source.Rating
+		res[i].Score = hit.Score
+	}
 
-	return nil
+	return res, nil
 }
 
-// Delete removes a film from the index.
-func (t *FilmSearchRepo) Delete(ctx context.Context, id string) error {
-	span := trace.SpanFromContext(ctx)
+// Search returns a page of the films matching p, by descending score unless sorted otherwise,
+// and the total number of films found.
+func (t *FilmSearchRepo) Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "FilmSearchRepo.Search")
 	defer span.End()
 
-	req := esv7api.DeleteRequest{
-		Index:      t.index,
-		DocumentID: id,
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(searchBody(p)); err != nil {
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.SearchRequest{
+		Index: []string{t.index},
+		Body:  &buf,
 	}
 
 	resp, err := req.Do(ctx, t.client)
 	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "DeleteRequest.Do")
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "SearchRequest.Do")
 	}
 	defer resp.Body.Close()
 
 	if resp.IsError() {
-		return internal.NewErrorf(internal.ErrorCodeUnknown, "DeleteRequest.Do %d", resp.StatusCode)
+		return models.FilmSearchResult{}, responseError("SearchRequest.Do", resp.StatusCode)
 	}
 
-	io.Copy(io.Discard, resp.Body)
-
-	return nil
-}
-
-// Search returns films matching a query.
-func (t *FilmSearchRepo) Search(
-	ctx context.Context,
-	name *string,
-	description *string,
-	releaseYear *uint16,
-	rating *float32,
-) ([]models.Film, error) {
-	span := trace.SpanFromContext(ctx)
-	defer span.End()
+	var hits struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source    indexedFilm         `json:"_source"`
+				Score     float64             `json:"_score"`
+				Sort      []interface{}       `json:"sort"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]struct {
+			Values struct {
+				Buckets []facetAggBucket `json:"buckets"`
+			} `json:"values"`
+		} `json:"aggregations"`
+	}
 
-	if name == nil && description == nil && releaseYear == nil && rating == nil {
-		return nil, nil
+	// NOTE: Sort values are kept as json.Number so they are sent back to ES unchanged.
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&hits); err != nil {
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
 	}
 
-	should := make([]interface{}, 0, 3)
+	res := models.FilmSearchResult{
+		Total:   hits.Hits.Total.Value,
+		Hits:    make([]models.FilmHit, len(hits.Hits.Hits)),
+		Backend: models.SearchBackendElasticsearch,
+	}
 
-	if name != nil {
-		should = append(should, map[string]interface{}{
-			"match": map[string]interface{}{
-				"name": *name,
-			},
-		})
+	for i, hit := range hits.Hits.Hits {
+		res.Hits[i].Id = hit.Source.Id
+		res.Hits[i].Name = hit.Source.Name
+		res.Hits[i].Description = hit.Source.Description
+		res.Hits[i].ReleaseYear = hit.Source.ReleaseYear
+		res.Hits[i].Rating = hit.Source.Rating
+		res.Hits[i].Score = hit.Score
+		res.Hits[i].Highlights = hit.Highlight
+		res.After = hit.Sort
 	}
 
-	if description != nil {
-		should = append(should, map[string]interface{}{
-			"match": map[string]interface{}{
-				"description": *description,
-			},
-		})
+	decades := make([]string, len(p.Decades))
+	for i, d := range p.Decades {
+		decades[i] = strconv.Itoa(int(d))
 	}
 
-	if releaseYear != nil {
-		should = append(should, map[string]interface{}{
-			"match": map[string]interface{}{
-				"release_year": *releaseYear,
-			},
-		})
+	selected := map[string][]string{
+		facetDecade: decades,
+		facetRating: p.Ratings,
 	}
 
-	if rating != nil {
-		should = append(should, map[string]interface{}{
-			"match": map[string]interface{}{
-				"rating": *rating,
-			},
+	res.Facets = make(map[string][]models.FacetBucket, len(facets))
+	for _, name := range facets {
+		res.Facets[name] = facetBuckets(hits.Aggregations[name].Values.Buckets, func(key string) bool {
+			return slices.Contains(selected[name], key)
 		})
 	}
 
-	var query map[string]interface{}
+	return res, nil
+}
 
-	if len(should) > 1 {
-		query = map[string]interface{}{
-			"query": map[string]interface{}{
-				"bool": map[string]interface{}{
-					"should": should,
-				},
-			},
+// searchBody returns the body of the search request for p. Text criteria are scored, ranges are
+// in filter context so they only include or exclude films.
+func searchBody(p m.SearchFilms) map[string]interface{} {
+	fields := searchFields(p.Boosts)
+
+	text := func(query string, extra map[string]interface{}) map[string]interface{} {
+		mm := map[string]interface{}{
+			"query":  query,
+			"fields": fields,
 		}
-	} else {
-		query = map[string]interface{}{
-			"query": should[0],
+		for k, v := range extra {
+			mm[k] = v
 		}
-	}
 
-	fmt.Printf("%#v\n", query)
+		return map[string]interface{}{"multi_match": mm}
+	}
 
-	var buf bytes.Buffer
+	fuzziness := p.Fuzziness
+	if fuzziness == "" {
+		fuzziness = "AUTO"
+	}
+	fuzzy := map[string]interface{}{"fuzziness": fuzziness}
+
+	must := make([]interface{}, 0, 1+len(p.Must)+len(p.Phrases))
+	should := make([]interface{}, 0, 1+len(p.Should))
+	if p.Query != "" {
+		must = append(must, text(p.Query, fuzzy))
+		if fuzziness != "0" {
+			// NOTE: Typos score as much as exact terms, exact matches are ranked first this way.
+			should = append(should, text(p.Query, nil))
+		}
+	}
+	for _, term := range p.Must {
+		must = append(must, text(term, map[string]interface{}{"operator": "and", "fuzziness": fuzziness}))
+	}
+	for _, phrase := range p.Phrases {
+		must = append(must, text(phrase, map[string]interface{}{"type": "phrase"}))
+	}
+	for _, term := range p.Should {
+		should = append(should, text(term, fuzzy))
+	}
 
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	filter := make([]interface{}, 0, 2)
+	if r := rangeQuery(p.YearFrom, p.YearTo); r != nil {
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"release_year": r}})
+	}
+	if r := rangeQuery(p.RatingMin, p.RatingMax); r != nil {
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"rating": r}})
 	}
 
-	req := esv7api.SearchRequest{
-		Index: []string{t.index},
-		Body:  &buf,
+	boolQuery := map[string]interface{}{}
+	if len(must) > 0 {
+		boolQuery["must"] = must
+	}
+	if len(filter) > 0 {
+		boolQuery["filter"] = filter
+	}
+	if len(should) > 0 {
+		boolQuery["should"] = should
+		// NOTE: Should clauses only raise the score of the films selected by the must and filter
+		// clauses. Without any, one of them has to match or every film would be found.
+		if len(must) > 0 || len(filter) > 0 {
+			boolQuery["minimum_should_match"] = 0
+		} else {
+			boolQuery["minimum_should_match"] = 1
+		}
 	}
 
-	resp, err := req.Do(ctx, t.client)
-	if err != nil {
-		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "SearchRequest.Do")
+	facetFilters := facetFilters(p)
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"bool": boolQuery},
+		"aggs":  facetAggs(facetFilters),
+		"highlight": map[string]interface{}{
+			"encoder": "html",
+			"fields": map[string]interface{}{
+				// NOTE: Names are short, they are highlighted whole.
+				"name":        map[string]interface{}{"number_of_fragments": 0},
+				"description": map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
+		},
+		"sort": searchSort(m.StableSort(p.SearchSort())),
+		"size": p.Limit,
+		// NOTE: ES stops counting at 10000 hits and doesn't score hits sorted by other fields
+		// unless told to.
+		"track_total_hits": true,
+		"track_scores":     true,
+	}
+	if f := postFilter(facetFilters); f != nil {
+		body["post_filter"] = f
+	}
+	if p.After != nil {
+		body["search_after"] = p.After
+	} else {
+		body["from"] = (p.Page - 1) * p.Limit
 	}
-	defer resp.Body.Close()
 
-	if resp.IsError() {
-		return nil, internal.NewErrorf(internal.ErrorCodeUnknown, "SearchRequest.Do %d", resp.StatusCode)
+	return body
+}
+
+// searchFields returns the text fields with their boosts, e.g. `name^2`.
+func searchFields(boosts map[string]float32) []string {
+	weights := map[string]float32{
+		"name":        2,
+		"description": 1,
+	}
+	for field, boost := range boosts {
+		weights[field] = boost
 	}
 
-	var hits struct {
-		Hits struct {
-			Hits []struct {
-				Source indexedFilm `json:"_source"`
-			} `json:"hits"`
-		} `json:"hits"`
+	return []string{
+		"name^" + strconv.FormatFloat(float64(weights["name"]), 'f', -1, 32),
+		"description^" + strconv.FormatFloat(float64(weights["description"]), 'f', -1, 32),
 	}
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil {
-		fmt.Println("Error here", err)
-		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+// searchSort returns the sort of the search request, `score` is the relevance.
+func searchSort(sort []m.SortField) []interface{} {
+	res := make([]interface{}, len(sort))
+	for i, s := range sort {
+		name := s.Name
+		if name == "score" {
+			name = "_score"
+		}
+
+		order := "asc"
+		if s.Desc {
+			order = "desc"
+		}
+
+		res[i] = map[string]interface{}{name: order}
 	}
 
-	res := make([]models.Film, len(hits.Hits.Hits))
+	return res
+}
 
-	for i, hit := range hits.Hits.Hits {
-		res[i].Id = hit.Source.Id
-		res[i].Name = hit.Source.Name
-		res[i].Description = hit.Source.Description
-		res[i].ReleaseYear = hit.Source.ReleaseYear
-		res[i].Rating = hit.Source.Rating
+// rangeQuery returns the bounds of a range query, nil when there are none.
+func rangeQuery[T uint16 | float32](from, to *T) map[string]interface{} {
+	if from == nil && to == nil {
+		return nil
 	}
 
-	return res, nil
+	r := map[string]interface{}{}
+	if from != nil {
+		r["gte"] = *from
+	}
+	if to != nil {
+		r["lte"] = *to
+	}
+
+	return r
 }
@@ -0,0 +1,97 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	m "filmoteka/internal/restapi/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the search request bodies")
+
+func TestSearchBody(t *testing.T) {
+	yearFrom, yearTo := uint16(1990), uint16(2010)
+	ratingMin := float32(6.5)
+
+	testCases := []struct {
+		name   string
+		params m.SearchFilms
+	}{
+		{
+			name: "fuzzy_boosted",
+			params: m.SearchFilms{
+				Query:     "pirates",
+				Must:      []string{"sea"},
+				Should:    []string{"captain"},
+				Phrases:   []string{"black pearl"},
+				Fuzziness: "1",
+				YearFrom:  &yearFrom,
+				YearTo:    &yearTo,
+				RatingMin: &ratingMin,
+				Boosts:    map[string]float32{"name": 3, "description": 0.5},
+				Sort:      []m.SortField{{Name: "rating", Desc: true}, {Name: "score", Desc: true}},
+				Limit:     10,
+				Page:      2,
+			},
+		},
+		{
+			name: "should_only",
+			params: m.SearchFilms{
+				Should: []string{"captain", "pearl"},
+				Limit:  20,
+				Page:   1,
+			},
+		},
+		{
+			name: "exact",
+			params: m.SearchFilms{
+				Query:     "pirates",
+				Fuzziness: "0",
+				Limit:     20,
+				Page:      1,
+			},
+		},
+		{
+			name: "facets",
+			params: m.SearchFilms{
+				Query:   "pirates",
+				Decades: []uint16{1990, 2000},
+				Ratings: []string{"9-10", "7-8"},
+				Limit:   20,
+				Page:    1,
+			},
+		},
+		{
+			name: "search_after",
+			params: m.SearchFilms{
+				Query: "pirates",
+				Limit: 20,
+				Page:  1,
+				After: []interface{}{json.Number("1.5"), json.Number("3")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.MarshalIndent(searchBody(tc.params), "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "search_"+tc.name+".json")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, append(got, '\n'), 0o644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "run go test -update to create the golden file")
+
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	esv7 "github.com/elastic/go-elasticsearch/v7"
+	esv7api "github.com/elastic/go-elasticsearch/v7/esapi"
+	"go.opentelemetry.io/otel"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/envvar"
+	"filmoteka/internal/tracing"
+)
+
+var tracer = otel.Tracer("filmoteka/internal/app/elacticsearch")
+
+// NewClient returns the Elasticsearch client of the configuration, every request gets a span. It
+// doesn't connect: Elasticsearch being down must not prevent the server from starting. Requests
+// aren't retried by the client, callers do, see service.ResilientFilmSearch.
+func NewClient(conf envvar.SearchConfig) (*esv7.Client, error) {
+	es, err := esv7.NewClient(esv7.Config{
+		Addresses: []string{conf.URL},
+		Transport: tracing.Transport(nil),
+		// NOTE: Retries on top of the callers' would multiply the calls before a failure is seen.
+		DisableRetry: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch.NewClient %w", err)
+	}
+
+	return es, nil
+}
+
+// responseError returns the error of an Elasticsearch error response: client errors are invalid
+// arguments, which retrying doesn't fix, except timeouts and throttling, which are unknown like
+// server errors.
+func responseError(op string, status int) error {
+	code := internal.ErrorCodeUnknown
+	if status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+		code = internal.ErrorCodeInvalidArgument
+	}
+
+	return internal.NewErrorf(code, "%s %d", op, status)
+}
+
+// put creates or updates the document with the id.
+func (i *aliasedIndex) put(ctx context.Context, id string, doc interface{}) error {
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.IndexRequest{
+		Index:      i.index,
+		Body:       &buf,
+		DocumentID: id,
+		Refresh:    "true",
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndexRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("IndexRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// remove deletes the document with the id, removing a missing document succeeds.
+func (i *aliasedIndex) remove(ctx context.Context, id string) error {
+	req := esv7api.DeleteRequest{
+		Index:      i.index,
+		DocumentID: id,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "DeleteRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	// NOTE: Not found means it is already gone, deletes are retried until they succeed.
+	if resp.IsError() && resp.StatusCode != http.StatusNotFound {
+		return responseError("DeleteRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// suggest returns up to limit documents whose name has words starting with the words of q.
+// Every word must be matched, whole words score higher than prefixes.
+func (i *aliasedIndex) suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error) {
+	var buf bytes.Buffer
+
+	body := map[string]interface{}{
+		"size":    limit,
+		"_source": []string{"id", "name"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"match": map[string]interface{}{
+						"name.prefix": map[string]interface{}{"query": q, "operator": "and"},
+					},
+				},
+				"should": map[string]interface{}{
+					"match": map[string]interface{}{"name": q},
+				},
+			},
+		},
+	}
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.SearchRequest{
+		Index: []string{i.index},
+		Body:  &buf,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "SearchRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, responseError("SearchRequest.Do", resp.StatusCode)
+	}
+
+	var hits struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Suggestion `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	res := make([]models.Suggestion, len(hits.Hits.Hits))
+	for j, hit := range hits.Hits.Hits {
+		res[j] = hit.Source
+	}
+
+	return res, nil
+}
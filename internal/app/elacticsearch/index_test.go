@@ -0,0 +1,37 @@
+package elasticsearch
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"filmoteka/internal"
+)
+
+func TestResponseError(t *testing.T) {
+	testCases := []struct {
+		status int
+		code   internal.ErrorCode
+	}{
+		{status: http.StatusBadRequest, code: internal.ErrorCodeInvalidArgument},
+		{status: http.StatusNotFound, code: internal.ErrorCodeInvalidArgument},
+		{status: http.StatusConflict, code: internal.ErrorCodeInvalidArgument},
+		{status: http.StatusRequestTimeout, code: internal.ErrorCodeUnknown},
+		{status: http.StatusTooManyRequests, code: internal.ErrorCodeUnknown},
+		{status: http.StatusInternalServerError, code: internal.ErrorCodeUnknown},
+		{status: http.StatusServiceUnavailable, code: internal.ErrorCodeUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			err := responseError("SearchRequest.Do", tc.status)
+
+			var ierr *internal.Error
+			if assert.True(t, errors.As(err, &ierr)) {
+				assert.Equal(t, tc.code, ierr.Code())
+			}
+		})
+	}
+}
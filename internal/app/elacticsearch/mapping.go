@@ -0,0 +1,419 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	esv7 "github.com/elastic/go-elasticsearch/v7"
+	esv7api "github.com/elastic/go-elasticsearch/v7/esapi"
+
+	"filmoteka/internal"
+)
+
+// filmsMappingVersion is the version of filmsIndex, it is stored in the `_meta` of the mapping.
+// Bump it whenever filmsIndex changes: indices with an older version aren't used until they are
+// rebuilt with the reindex command, see Bootstrap.
+const filmsMappingVersion = 3
+
+// aliasedIndex manages the versioned indices behind an alias, searches and writes go through
+// the alias so that indices can be rebuilt or migrated without downtime.
+type aliasedIndex struct {
+	client *esv7.Client
+	// index is the name of the alias.
+	index string
+	// definition returns the settings and the mapping of the indices, its `_meta` holds version.
+	definition func() map[string]interface{}
+	version    int
+}
+
+// filmsIndex returns the settings and the mapping of the films indices.
+func filmsIndex() map[string]interface{} {
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"analysis": map[string]interface{}{
+				"analyzer": map[string]interface{}{
+					// Full text: case and accent insensitive, English stemming.
+					"film_text": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding", "english_stemmer"},
+					},
+					// Type-ahead: every prefix of every word is indexed, queries aren't split into
+					// prefixes so they match the words they start.
+					"film_prefix": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding", "prefixes"},
+					},
+					"film_prefix_search": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding"},
+					},
+					// Names of the cast: case and accent insensitive, no stemming.
+					"film_actor": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding"},
+					},
+				},
+				"filter": map[string]interface{}{
+					"english_stemmer": map[string]interface{}{
+						"type":     "stemmer",
+						"language": "english",
+					},
+					"prefixes": prefixesFilter(),
+				},
+				"normalizer": map[string]interface{}{
+					// Exact matching: case and accent insensitive.
+					"film_keyword": map[string]interface{}{
+						"type":   "custom",
+						"filter": []string{"lowercase", "asciifolding"},
+					},
+				},
+			},
+		},
+		"mappings": map[string]interface{}{
+			"dynamic": "strict",
+			"_meta": map[string]interface{}{
+				"mapping_version": filmsMappingVersion,
+			},
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{
+					"type": "integer",
+				},
+				"name": map[string]interface{}{
+					"type":     "text",
+					"analyzer": "film_text",
+					"fields": map[string]interface{}{
+						"keyword": map[string]interface{}{
+							"type":         "keyword",
+							"normalizer":   "film_keyword",
+							"ignore_above": 256,
+						},
+						"prefix": map[string]interface{}{
+							"type":            "text",
+							"analyzer":        "film_prefix",
+							"search_analyzer": "film_prefix_search",
+						},
+					},
+				},
+				"description": map[string]interface{}{
+					"type":     "text",
+					"analyzer": "film_text",
+				},
+				"release_year": map[string]interface{}{
+					"type": "short",
+				},
+				"rating": map[string]interface{}{
+					"type": "float",
+				},
+				// Names of the cast, read from the database when the index is rebuilt.
+				"actors": map[string]interface{}{
+					"type":     "text",
+					"analyzer": "film_actor",
+				},
+			},
+		},
+	}
+}
+
+// actorsMappingVersion is the version of actorsIndex, see filmsMappingVersion.
+const actorsMappingVersion = 1
+
+// actorsIndex returns the settings and the mapping of the actors indices.
+func actorsIndex() map[string]interface{} {
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"analysis": map[string]interface{}{
+				"analyzer": map[string]interface{}{
+					// Names: case and accent insensitive, no stemming.
+					"actor_name": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding"},
+					},
+					// Type-ahead, see filmsIndex.
+					"actor_prefix": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding", "prefixes"},
+					},
+				},
+				"filter": map[string]interface{}{
+					"prefixes": prefixesFilter(),
+				},
+				"normalizer": map[string]interface{}{
+					"actor_keyword": map[string]interface{}{
+						"type":   "custom",
+						"filter": []string{"lowercase", "asciifolding"},
+					},
+				},
+			},
+		},
+		"mappings": map[string]interface{}{
+			"dynamic": "strict",
+			"_meta": map[string]interface{}{
+				"mapping_version": actorsMappingVersion,
+			},
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{
+					"type": "integer",
+				},
+				"name": map[string]interface{}{
+					"type":     "text",
+					"analyzer": "actor_name",
+					"fields": map[string]interface{}{
+						"keyword": map[string]interface{}{
+							"type":         "keyword",
+							"normalizer":   "actor_keyword",
+							"ignore_above": 256,
+						},
+						"prefix": map[string]interface{}{
+							"type":            "text",
+							"analyzer":        "actor_prefix",
+							"search_analyzer": "actor_name",
+						},
+					},
+				},
+				"gender": map[string]interface{}{
+					"type": "keyword",
+				},
+				"birth_date": map[string]interface{}{
+					"type":   "date",
+					"format": "yyyy-MM-dd",
+				},
+			},
+		},
+	}
+}
+
+// prefixesFilter returns the token filter indexing the prefixes of the words, up to 20
+// characters long.
+func prefixesFilter() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "edge_ngram",
+		"min_gram": 1,
+		"max_gram": 20,
+	}
+}
+
+// Bootstrap makes sure the index searches use exists and has the current mapping. A missing
+// index is created together with the alias. An index with another mapping version, or lacking
+// anything declared in the mapping, is an ErrorCodePreconditionFailed error: retrying doesn't
+// fix it, an older index is rebuilt from the database with the reindex command.
+func (i *aliasedIndex) Bootstrap(ctx context.Context) error {
+	found, err := i.exists(ctx, i.index)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		err := i.createAliased(ctx)
+		if err == nil {
+			return nil
+		}
+
+		// NOTE: Another replica may have created it meanwhile, it's verified like any other.
+		if found, _ := i.exists(ctx, i.index); !found {
+			return err
+		}
+	}
+
+	mappings, err := i.mappings(ctx)
+	if err != nil {
+		return err
+	}
+
+	for index, mapping := range mappings {
+		version := metaVersion(mapping)
+
+		switch {
+		case version > i.version:
+			return internal.NewErrorf(internal.ErrorCodePreconditionFailed,
+				"index %s mapping version %d is newer than %d", index, version, i.version)
+		case version < i.version:
+			return internal.NewErrorf(internal.ErrorCodePreconditionFailed,
+				"index %s mapping version %d is older than %d, rebuild it with the reindex command", index, version, i.version)
+		}
+
+		if err := verifyMapping(i.definition()["mappings"], mapping); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodePreconditionFailed, "index %s", index)
+		}
+	}
+
+	return nil
+}
+
+// createAliased creates the first index, named after the mapping version, e.g. `films_v3`,
+// together with the alias. Replicas bootstrapping concurrently create the same index, so only
+// one of them succeeds.
+func (i *aliasedIndex) createAliased(ctx context.Context) error {
+	definition := i.definition()
+	definition["aliases"] = map[string]interface{}{i.index: map[string]interface{}{}}
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(definition); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.IndicesCreateRequest{
+		Index: i.index + "_v" + strconv.Itoa(i.version),
+		Body:  &buf,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesCreateRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("IndicesCreateRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// copyIndex copies the documents of the index from into the index to with `_reindex`. A missing
+// index to is created with dynamic mapping.
+func (i *aliasedIndex) copyIndex(ctx context.Context, from, to string) error {
+	var buf bytes.Buffer
+
+	body := map[string]interface{}{
+		"source": map[string]interface{}{"index": from},
+		"dest":   map[string]interface{}{"index": to},
+	}
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	refresh := true
+	wait := true
+
+	req := esv7api.ReindexRequest{
+		Body:              &buf,
+		Refresh:           &refresh,
+		WaitForCompletion: &wait,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "ReindexRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("ReindexRequest.Do", resp.StatusCode)
+	}
+
+	var res struct {
+		Failures []interface{} `json:"failures"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	if len(res.Failures) > 0 {
+		return internal.NewErrorf(internal.ErrorCodeUnknown, "ReindexRequest.Do %s: %d failures", from, len(res.Failures))
+	}
+
+	return nil
+}
+
+// mappings returns the mapping of every index behind the alias.
+func (i *aliasedIndex) mappings(ctx context.Context) (map[string]map[string]interface{}, error) {
+	req := esv7api.IndicesGetMappingRequest{
+		Index: []string{i.index},
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesGetMappingRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, internal.NewErrorf(internal.ErrorCodeUnknown, "IndicesGetMappingRequest.Do %d", resp.StatusCode)
+	}
+
+	var res map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	mappings := make(map[string]map[string]interface{}, len(res))
+	for index, m := range res {
+		mappings[index] = m.Mappings
+	}
+
+	return mappings, nil
+}
+
+// metaVersion returns the mapping version stored in mapping, 0 for indices created with
+// dynamic mapping.
+func metaVersion(mapping map[string]interface{}) int {
+	meta, _ := mapping["_meta"].(map[string]interface{})
+
+	switch v := meta["mapping_version"].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+
+	return 0
+}
+
+// verifyMapping returns an error when mapping lacks anything declared in declared.
+func verifyMapping(declared interface{}, mapping map[string]interface{}) error {
+	// NOTE: Round trip through JSON so both sides hold the same types.
+	content, err := json.Marshal(declared)
+	if err != nil {
+		return err
+	}
+
+	var want map[string]interface{}
+	if err := json.Unmarshal(content, &want); err != nil {
+		return err
+	}
+
+	return subset("mappings", want, mapping)
+}
+
+// subset returns an error naming the first path of want missing or different in got.
+func subset(path string, want, got interface{}) error {
+	wantMap, ok := want.(map[string]interface{})
+	if !ok {
+		if !reflect.DeepEqual(want, got) {
+			return fmt.Errorf("%s is %v, want %v", path, got, want)
+		}
+		return nil
+	}
+
+	gotMap, ok := got.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s is missing", path)
+	}
+
+	for k, v := range wantMap {
+		if err := subset(path+"."+k, v, gotMap[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
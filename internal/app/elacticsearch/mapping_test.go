@@ -0,0 +1,235 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	esv7 "github.com/elastic/go-elasticsearch/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+func TestMetaVersion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mapping string
+		version int
+	}{
+		{name: "dynamic", mapping: `{"properties":{"name":{"type":"text"}}}`, version: 0},
+		{name: "number", mapping: `{"_meta":{"mapping_version":2}}`, version: 2},
+		{name: "string", mapping: `{"_meta":{"mapping_version":"3"}}`, version: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mapping map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.mapping), &mapping))
+
+			assert.Equal(t, tc.version, metaVersion(mapping))
+		})
+	}
+}
+
+func TestVerifyMapping(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(properties map[string]interface{})
+		err    string
+	}{
+		{
+			name:   "declared",
+			change: func(properties map[string]interface{}) {},
+		},
+		{
+			// NOTE: Elasticsearch returns settings left to their defaults too.
+			name: "extra",
+			change: func(properties map[string]interface{}) {
+				properties["name"].(map[string]interface{})["norms"] = true
+			},
+		},
+		{
+			name: "different type",
+			change: func(properties map[string]interface{}) {
+				properties["rating"].(map[string]interface{})["type"] = "double"
+			},
+			err: "mappings.properties.rating.type is double, want float",
+		},
+		{
+			name: "missing field",
+			change: func(properties map[string]interface{}) {
+				delete(properties["name"].(map[string]interface{})["fields"].(map[string]interface{}), "keyword")
+			},
+			err: "mappings.properties.name.fields.keyword is missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// NOTE: Round trip through JSON like the mapping returned by Elasticsearch.
+			content, err := json.Marshal(filmsIndex()["mappings"])
+			require.NoError(t, err)
+
+			var mapping map[string]interface{}
+			require.NoError(t, json.Unmarshal(content, &mapping))
+
+			tc.change(mapping["properties"].(map[string]interface{}))
+
+			err = verifyMapping(filmsIndex()["mappings"], mapping)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Equal(t, tc.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestFilmsIndex_mapsDocuments(t *testing.T) {
+	properties := filmsIndex()["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	content, err := json.Marshal(newIndexedFilm(models.Film{
+		Id:          1,
+		Name:        "Film 1",
+		Description: "Desc 1",
+		ReleaseYear: 2003,
+		Rating:      8,
+		Actors:      []string{"Actor 1", "Actor 2"},
+	}))
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &doc))
+
+	// NOTE: The mapping is strict, unmapped fields are rejected.
+	for field := range doc {
+		assert.Contains(t, properties, field)
+	}
+	for _, field := range similarFields {
+		assert.Contains(t, doc, field)
+	}
+}
+
+func TestAliasedIndex_Bootstrap(t *testing.T) {
+	testCases := []struct {
+		name    string
+		missing bool
+		change  func(mapping map[string]interface{})
+		created bool
+		isErr   bool
+	}{
+		{
+			name:    "missing",
+			missing: true,
+			created: true,
+		},
+		{
+			name:   "current",
+			change: func(mapping map[string]interface{}) {},
+		},
+		{
+			name: "dynamic",
+			change: func(mapping map[string]interface{}) {
+				delete(mapping, "_meta")
+				mapping["dynamic"] = "true"
+			},
+			isErr: true,
+		},
+		{
+			name: "older",
+			change: func(mapping map[string]interface{}) {
+				mapping["_meta"] = map[string]interface{}{"mapping_version": filmsMappingVersion - 1}
+			},
+			isErr: true,
+		},
+		{
+			name: "newer",
+			change: func(mapping map[string]interface{}) {
+				mapping["_meta"] = map[string]interface{}{"mapping_version": filmsMappingVersion + 1}
+			},
+			isErr: true,
+		},
+		{
+			name: "different",
+			change: func(mapping map[string]interface{}) {
+				properties := mapping["properties"].(map[string]interface{})
+				properties["rating"].(map[string]interface{})["type"] = "double"
+			},
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := json.Marshal(filmsIndex()["mappings"])
+			require.NoError(t, err)
+
+			var mapping map[string]interface{}
+			require.NoError(t, json.Unmarshal(content, &mapping))
+
+			if tc.change != nil {
+				tc.change(mapping)
+			}
+
+			var created bool
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/":
+					// NOTE: The product check of the client.
+					w.Write([]byte(`{"version":{"number":"7.17.10","build_flavor":"default"},"tagline":"You Know, for Search"}`))
+				case r.Method == http.MethodHead && r.URL.Path == "/films":
+					if tc.missing {
+						w.WriteHeader(http.StatusNotFound)
+					}
+				case r.Method == http.MethodPut && r.URL.Path == "/films_v3":
+					var body struct {
+						Aliases map[string]interface{} `json:"aliases"`
+					}
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					assert.Contains(t, body.Aliases, "films")
+
+					created = true
+					w.Write([]byte(`{"acknowledged":true}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/films/_mapping":
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"films_1": map[string]interface{}{"mappings": mapping},
+					})
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer srv.Close()
+
+			client, err := esv7.NewClient(esv7.Config{Addresses: []string{srv.URL}})
+			require.NoError(t, err)
+
+			err = NewFilmSearchRepo(client, "films").Bootstrap(context.Background())
+
+			assert.Equal(t, tc.created, created)
+
+			if !tc.isErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			// NOTE: Retrying doesn't fix the mapping, the server doesn't start.
+			var ierr *internal.Error
+			if assert.True(t, errors.As(err, &ierr)) {
+				assert.Equal(t, internal.ErrorCodePreconditionFailed, ierr.Code())
+			}
+		})
+	}
+}
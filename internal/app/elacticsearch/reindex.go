@@ -0,0 +1,330 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	esv7api "github.com/elastic/go-elasticsearch/v7/esapi"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// CreateIndex creates a new empty versioned index with the current mapping, e.g.
+// `films_20240102150405`, and returns its name. It is not searched until SwapAlias points the
+// alias to it.
+func (i *aliasedIndex) CreateIndex(ctx context.Context) (string, error) {
+	name := i.index + "_" + time.Now().UTC().Format("20060102150405")
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(i.definition()); err != nil {
+		return "", internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.IndicesCreateRequest{
+		Index: name,
+		Body:  &buf,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return "", internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesCreateRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return "", responseError("IndicesCreateRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return name, nil
+}
+
+// BulkIndex indexes films into index with one `_bulk` request.
+func (f *FilmSearchRepo) BulkIndex(ctx context.Context, index string, films []models.Film) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	for _, film := range films {
+		action := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": index,
+				"_id":    strconv.Itoa(film.Id),
+			},
+		}
+		if err := enc.Encode(action); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+		}
+		if err := enc.Encode(newIndexedFilm(film)); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+		}
+	}
+
+	return f.bulk(ctx, &buf)
+}
+
+// BulkDelete removes the films with the ids from index with one `_bulk` request, films missing
+// from it are ignored.
+func (f *FilmSearchRepo) BulkDelete(ctx context.Context, index string, ids []string) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		action := map[string]interface{}{
+			"delete": map[string]interface{}{
+				"_index": index,
+				"_id":    id,
+			},
+		}
+		if err := enc.Encode(action); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+		}
+	}
+
+	return f.bulk(ctx, &buf)
+}
+
+// bulk sends the actions of body in a `_bulk` request and fails on the first failed action.
+func (f *FilmSearchRepo) bulk(ctx context.Context, body io.Reader) error {
+	req := esv7api.BulkRequest{
+		Body: body,
+	}
+
+	resp, err := req.Do(ctx, f.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "BulkRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("BulkRequest.Do", resp.StatusCode)
+	}
+
+	// NOTE: The request succeeds even when some of the documents fail.
+	var res struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Id     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	if res.Errors {
+		for _, item := range res.Items {
+			for action, r := range item {
+				// NOTE: Deleting a film which isn't indexed is not a failure.
+				if action == "delete" && r.Status == http.StatusNotFound {
+					continue
+				}
+				if r.Status >= http.StatusBadRequest {
+					return internal.NewErrorf(internal.ErrorCodeUnknown, "BulkRequest.Do %s film %s: %s", action, r.Id, r.Error.Reason)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Refresh makes everything indexed into index searchable.
+func (i *aliasedIndex) Refresh(ctx context.Context, index string) error {
+	req := esv7api.IndicesRefreshRequest{
+		Index: []string{index},
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesRefreshRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("IndicesRefreshRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// SwapAlias atomically points the alias searches use to index and returns the indices it
+// pointed to before. A concrete index named like the alias, as created before the alias
+// existed, is copied first into `<alias>_legacy_<timestamp>`, which is returned instead: the
+// alias can only be added once the concrete index is removed, which happens in the same request.
+func (i *aliasedIndex) SwapAlias(ctx context.Context, index string) ([]string, error) {
+	current, err := i.aliased(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := current
+
+	actions := []interface{}{
+		map[string]interface{}{
+			"add": map[string]interface{}{"index": index, "alias": i.index},
+		},
+	}
+
+	if current == nil {
+		concrete, err := i.exists(ctx, i.index)
+		if err != nil {
+			return nil, err
+		}
+		if concrete {
+			// NOTE: Kept like any previous index until the caller is done with it, e.g. replayed
+			// the changes made meanwhile.
+			legacy := i.index + "_legacy_" + time.Now().UTC().Format("20060102150405")
+			if err := i.copyIndex(ctx, i.index, legacy); err != nil {
+				return nil, err
+			}
+
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{"index": i.index},
+			})
+			previous = []string{legacy}
+		}
+	}
+
+	for _, old := range current {
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": old, "alias": i.index},
+		})
+	}
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"actions": actions}); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewEncoder.Encode")
+	}
+
+	req := esv7api.IndicesUpdateAliasesRequest{
+		Body: &buf,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesUpdateAliasesRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, responseError("IndicesUpdateAliasesRequest.Do", resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return previous, nil
+}
+
+// DeleteIndex deletes the indices.
+func (i *aliasedIndex) DeleteIndex(ctx context.Context, indices ...string) error {
+	if len(indices) == 0 {
+		return nil
+	}
+
+	req := esv7api.IndicesDeleteRequest{
+		Index: indices,
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesDeleteRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return internal.NewErrorf(internal.ErrorCodeUnknown, "IndicesDeleteRequest.Do %s %d", strings.Join(indices, ","), resp.StatusCode)
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// aliased returns the indices the alias points to, nil when there is no such alias.
+func (i *aliasedIndex) aliased(ctx context.Context) ([]string, error) {
+	req := esv7api.IndicesGetAliasRequest{
+		Name: []string{i.index},
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesGetAliasRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return nil, nil
+	}
+
+	if resp.IsError() {
+		return nil, responseError("IndicesGetAliasRequest.Do", resp.StatusCode)
+	}
+
+	var res map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.NewDecoder.Decode")
+	}
+
+	indices := make([]string, 0, len(res))
+	for index := range res {
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+// exists reports whether there is an index or an alias named index.
+func (i *aliasedIndex) exists(ctx context.Context, index string) (bool, error) {
+	req := esv7api.IndicesExistsRequest{
+		Index: []string{index},
+	}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return false, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "IndicesExistsRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, responseError("IndicesExistsRequest.Do", resp.StatusCode)
+	}
+}
+
+// Ping checks that the cluster answers.
+func (i *aliasedIndex) Ping(ctx context.Context) error {
+	req := esv7api.PingRequest{}
+
+	resp, err := req.Do(ctx, i.client)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "PingRequest.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return responseError("PingRequest.Do", resp.StatusCode)
+	}
+
+	return nil
+}
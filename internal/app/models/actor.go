@@ -15,6 +15,8 @@ type Actor struct {
 	Gender string `json:"gender" validate:"required,len=1" example:"M"`
 	// Birth date in 2006-01-02 format
 	BirthDate string `json:"birth_date" validate:"required" example:"1963-06-09"`
+	// Version is incremented on every change, it is sent as the ETag header.
+	Version int `json:"-"`
 }
 
 func (a *Actor) Validate() error {
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Circuit breaker states: closed lets calls through, open fails them fast and half open lets a
+// single trial call through to find out whether the backend recovered.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStats describes the state of the circuit breaker guarding a backend.
+type BreakerStats struct {
+	// Name of the backend guarded.
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// Failures is the number of consecutive failed calls.
+	Failures int `json:"failures"`
+	// OpenedAt is when the breaker last opened, if it's not closed.
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	// Opens is the number of times the breaker opened since the start.
+	Opens uint64 `json:"opens"`
+	// Rejected is the number of calls failed fast since the start.
+	Rejected uint64 `json:"rejected"`
+	// Retries is the number of calls retried since the start.
+	Retries uint64 `json:"retries"`
+}
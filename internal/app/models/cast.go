@@ -0,0 +1,23 @@
+package models
+
+// CastMember is an actor credited in a film.
+type CastMember struct {
+	ActorId int `json:"actor_id"`
+	// Actors name
+	Name string `json:"name" example:"Johnny Depp"`
+	// Character played in the film
+	CharacterName string `json:"character_name" example:"Jack Sparrow"`
+	// Position in the credits, lower goes first
+	BillingOrder uint16 `json:"billing_order" example:"1"`
+}
+
+// Role is a film an actor is credited in.
+type Role struct {
+	FilmId      int    `json:"film_id"`
+	Name        string `json:"name"`
+	ReleaseYear uint16 `json:"release_year"`
+	// Character played in the film
+	CharacterName string `json:"character_name" example:"Jack Sparrow"`
+	// Position in the credits, lower goes first
+	BillingOrder uint16 `json:"billing_order" example:"1"`
+}
@@ -10,6 +10,10 @@ type Film struct {
 	Description string  `json:"description" validate:"required,min=5,max=500"`
 	ReleaseYear uint16  `json:"release_year" validate:"required,gte=1900,lte=2030"`
 	Rating      float32 `json:"rating" validate:"required,gte=0,lte=10"`
+	// Version is incremented on every change, it is sent as the ETag header.
+	Version int `json:"-"`
+	// Actors are the names of the cast in billing order, they are only read to be indexed.
+	Actors []string `json:"-"`
 }
 
 func (f *Film) Validate() error {
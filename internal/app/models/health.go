@@ -0,0 +1,26 @@
+package models
+
+// Health statuses: ok when every dependency is, degraded when only non critical ones are failing,
+// failing when a critical one is, and shutting down once a shutdown started.
+const (
+	HealthOK           = "ok"
+	HealthDegraded     = "degraded"
+	HealthFailing      = "failing"
+	HealthShuttingDown = "shutting_down"
+)
+
+// Health is the readiness of the service and of each of its dependencies.
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the outcome of checking a dependency.
+type HealthCheck struct {
+	// Status is either ok or failing
+	Status string `json:"status"`
+	// Critical dependencies failing make the service unready, others degrade it
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	// EntityFilm is the entity of the outbox events of Film records.
+	EntityFilm = "film"
+	// EntityActor is the entity of the outbox events of Actor records.
+	EntityActor = "actor"
+
+	// OperationIndex means the search index must be updated with the current record.
+	OperationIndex = "index"
+	// OperationDelete means the record must be removed from the search index.
+	OperationDelete = "delete"
+)
+
+// OutboxEvent is a change of a record waiting to be delivered to the search index.
+type OutboxEvent struct {
+	Id        int64
+	Entity    string
+	EntityId  int
+	Operation string
+	// Attempts is the number of deliveries tried so far, including the current one.
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// OutboxStats describes the events waiting in the outbox.
+type OutboxStats struct {
+	// Pending is the number of events not delivered yet.
+	Pending int `json:"pending"`
+	// Retrying is the number of pending events that failed at least once.
+	Retrying int `json:"retrying"`
+	// LagSeconds is the age of the oldest pending event.
+	LagSeconds float64 `json:"lag_seconds"`
+	// Dead is the number of events parked after failing every attempt, they are not delivered
+	// anymore.
+	Dead int `json:"dead"`
+}
+
+// RelayStats describes the progress of the delivery of the outbox to the search index.
+type RelayStats struct {
+	OutboxStats
+	// Delivered is the number of events delivered since the relay started.
+	Delivered uint64 `json:"delivered"`
+	// Failed is the number of failed deliveries since the relay started.
+	Failed uint64 `json:"failed"`
+}
@@ -0,0 +1,70 @@
+package models
+
+const (
+	// SearchBackendElasticsearch and SearchBackendPostgres name the backends serving searches.
+	SearchBackendElasticsearch = "elasticsearch"
+	SearchBackendPostgres      = "postgresql"
+
+	// FacetDecade and FacetRating name the facets of film searches.
+	FacetDecade = "decade"
+	FacetRating = "rating"
+)
+
+// FilmHit is a film found by a search.
+type FilmHit struct {
+	Film
+	// Relevance of the film to the search, higher is better
+	Score float64 `json:"score"`
+	// Highlights hold the fragments of the name and of the description matching the search,
+	// HTML escaped with the matches wrapped in <em> tags
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// FilmSearchResult is a page of the films found by a search.
+type FilmSearchResult struct {
+	// Total number of films found
+	Total int
+	Hits  []FilmHit
+	// After holds the sort values of the last hit, searching after them returns the next page.
+	After []interface{}
+	// Facets hold the number of films found for every value of the decade and the rating
+	// facets. The counts of a facet ignore its own selection, so they show what selecting
+	// another value would find.
+	Facets map[string][]FacetBucket
+	// Backend names the backend which served the search, see SearchBackendElasticsearch.
+	Backend string
+}
+
+// FacetBucket is a value of a facet and the number of films found having it.
+type FacetBucket struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	// Selected is true when the search is filtered by the value
+	Selected bool `json:"selected"`
+}
+
+// Suggestion is a film or an actor whose name starts like the text typed so far.
+type Suggestion struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Suggestions are the films and the actors suggested for the text typed so far, best matches first.
+type Suggestions struct {
+	Films  []Suggestion `json:"films"`
+	Actors []Suggestion `json:"actors"`
+}
+
+// ActorHit is an actor found by a search.
+type ActorHit struct {
+	Actor
+	// Relevance of the actor to the search, higher is better
+	Score float64 `json:"score"`
+}
+
+// ActorSearchResult is a page of the actors found by a search.
+type ActorSearchResult struct {
+	// Total number of actors found
+	Total int
+	Hits  []ActorHit
+}
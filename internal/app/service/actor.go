@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"filmoteka/internal"
@@ -10,32 +11,46 @@ import (
 
 // ActorRepository defines the datastore handling Actor records.
 type ActorRepository interface {
-	Create(f m.CreateActor) (models.Actor, error)
-	Delete(id string) error
-	SearchBy() ([]models.Actor, error)
-	Find(id string) (models.Actor, error)
-	Update(id string, f m.UpdateActor) error
+	Create(ctx context.Context, f m.CreateActor) (models.Actor, error)
+	Delete(ctx context.Context, id string, version int) error
+	SearchBy(ctx context.Context, p m.ListActors) ([]models.Actor, int, error)
+	Find(ctx context.Context, id string) (models.Actor, error)
+	Update(ctx context.Context, id string, f m.UpdateActor, version int) (int, error)
+	Patch(ctx context.Context, id string, a m.PatchActor, version int) error
 }
 
-// Task defines the application service in charge of interacting with Tasks.
+// ActorSearchRepository defines the datastore handling persisting Searchable Actor records.
+type ActorSearchRepository interface {
+	Delete(ctx context.Context, id string) error
+	Index(ctx context.Context, actor models.Actor) error
+	Search(ctx context.Context, p m.SearchActors) (models.ActorSearchResult, error)
+}
+
+// Task defines the application service in charge of interacting with Tasks. Changes reach the
+// search index through the outbox written by the repository, see SearchRelay.
 type ActorService struct {
-	repo ActorRepository
+	repo   ActorRepository
+	search ActorSearchRepository
 }
 
 // NewActorService
-func NewActorService(repo ActorRepository) *ActorService {
+func NewActorService(repo ActorRepository, search ActorSearchRepository) *ActorService {
 	return &ActorService{
-		repo: repo,
+		repo:   repo,
+		search: search,
 	}
 }
 
 // Create stores a new record.
-func (s *ActorService) Create(a m.CreateActor) (models.Actor, error) {
+func (s *ActorService) Create(ctx context.Context, a m.CreateActor) (models.Actor, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.Create")
+	defer span.End()
+
 	if err := a.Validate(); err != nil {
 		return models.Actor{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate actor")
 	}
 
-	actor, err := s.repo.Create(a)
+	actor, err := s.repo.Create(ctx, a)
 	if err != nil {
 		return models.Actor{}, fmt.Errorf("repo create: %w", err)
 	}
@@ -43,9 +58,13 @@ func (s *ActorService) Create(a m.CreateActor) (models.Actor, error) {
 	return actor, nil
 }
 
-// Delete removes an existing Actor from the datastore.
-func (s *ActorService) Delete(id string) error {
-	if err := s.repo.Delete(id); err != nil {
+// Delete removes an existing Actor from the datastore, when version is not 0 only if it's the
+// current version.
+func (s *ActorService) Delete(ctx context.Context, id string, version int) error {
+	ctx, span := tracer.Start(ctx, "ActorService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id, version); err != nil {
 		return fmt.Errorf("repo delete: %w", err)
 	}
 
@@ -53,8 +72,11 @@ func (s *ActorService) Delete(id string) error {
 }
 
 // Find gets an existing Actor from the datastore.
-func (s *ActorService) Find(id string) (models.Actor, error) {
-	task, err := s.repo.Find(id)
+func (s *ActorService) Find(ctx context.Context, id string) (models.Actor, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.Find")
+	defer span.End()
+
+	task, err := s.repo.Find(ctx, id)
 	if err != nil {
 		return models.Actor{}, fmt.Errorf("repo find: %w", err)
 	}
@@ -62,25 +84,127 @@ func (s *ActorService) Find(id string) (models.Actor, error) {
 	return task, nil
 }
 
-// Search gets all existing Actors from the datastore.
-func (s *ActorService) Search() ([]models.Actor, error) {
-	actors, err := s.repo.SearchBy()
+// Search gets a page of existing Actors from the datastore and the total number of matches.
+func (s *ActorService) Search(ctx context.Context, p m.ListActors) ([]models.Actor, int, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.Search")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate list")
+	}
+
+	actors, total, err := s.repo.SearchBy(ctx, p)
 	if err != nil {
-		return nil, fmt.Errorf("repo find: %w", err)
+		return nil, 0, fmt.Errorf("repo find: %w", err)
 	}
 
-	return actors, nil
+	return actors, total, nil
 }
 
-// Update updates an existing Actor in the datastore.
-func (s *ActorService) Update(id string, a m.UpdateActor) error {
+// FullTextSearch gets a page of the Actors matching p from the search index and the total
+// number of matches.
+func (s *ActorService) FullTextSearch(ctx context.Context, p m.SearchActors) (models.ActorSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.FullTextSearch")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return models.ActorSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate search")
+	}
+
+	res, err := s.search.Search(ctx, p)
+	if err != nil {
+		return models.ActorSearchResult{}, fmt.Errorf("search: %w", err)
+	}
+
+	return res, nil
+}
+
+// Update updates an existing Actor in the datastore, when version is not 0 only if it's the
+// current version, and returns its new version.
+func (s *ActorService) Update(ctx context.Context, id string, a m.UpdateActor, version int) (int, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.Update")
+	defer span.End()
+
 	if err := a.Validate(); err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate actor")
+		return 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate actor")
 	}
 
-	if err := s.repo.Update(id, a); err != nil {
-		return fmt.Errorf("repo update: %w", err)
+	updated, err := s.repo.Update(ctx, id, a, version)
+	if err != nil {
+		return 0, fmt.Errorf("repo update: %w", err)
 	}
 
-	return nil
+	return updated, nil
+}
+
+// Patch applies a partial update to an existing Actor, only the changed fields are written. When
+// version is not 0 the Actor is only updated if it's the current version, otherwise the patch is
+// applied again when the Actor changes meanwhile, see retryConflicts.
+func (s *ActorService) Patch(ctx context.Context, id string, p m.Patch, version int) (models.Actor, error) {
+	ctx, span := tracer.Start(ctx, "ActorService.Patch")
+	defer span.End()
+
+	var actor models.Actor
+	err := retryConflicts(version, func() error {
+		var err error
+		actor, err = s.patch(ctx, id, p, version)
+		return err
+	})
+
+	return actor, err
+}
+
+// patch applies p to the Actor as currently stored, the changes are only written if it's still the
+// version read.
+func (s *ActorService) patch(ctx context.Context, id string, p m.Patch, version int) (models.Actor, error) {
+	actor, err := s.repo.Find(ctx, id)
+	if err != nil {
+		return models.Actor{}, fmt.Errorf("repo find: %w", err)
+	}
+
+	if version != 0 && version != actor.Version {
+		return models.Actor{}, internal.NewErrorf(internal.ErrorCodePreconditionFailed, "version %d is not current", version)
+	}
+
+	current := m.UpdateActor{
+		Name:      actor.Name,
+		Gender:    actor.Gender,
+		BirthDate: actor.BirthDate,
+	}
+
+	var patched m.UpdateActor
+	if err := applyPatch(p, current, &patched); err != nil {
+		return models.Actor{}, err
+	}
+
+	if err := patched.Validate(); err != nil {
+		return models.Actor{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate actor")
+	}
+
+	var changes m.PatchActor
+	if patched.Name != current.Name {
+		changes.Name = &patched.Name
+	}
+	if patched.Gender != current.Gender {
+		changes.Gender = &patched.Gender
+	}
+	if patched.BirthDate != current.BirthDate {
+		changes.BirthDate = &patched.BirthDate
+	}
+
+	if changes == (m.PatchActor{}) {
+		return actor, nil
+	}
+
+	// NOTE: Always conditional, the Actor may have changed since it was read, see Patch.
+	if err := s.repo.Patch(ctx, id, changes, actor.Version); err != nil {
+		return models.Actor{}, fmt.Errorf("repo patch: %w", err)
+	}
+
+	actor.Name = patched.Name
+	actor.Gender = patched.Gender
+	actor.BirthDate = patched.BirthDate
+	actor.Version++
+
+	return actor, nil
 }
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+const (
+	searchTimeout = 2 * time.Second
+	writeTimeout  = 5 * time.Second
+
+	retryAttempts  = 3
+	retryBaseDelay = 100 * time.Millisecond
+
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// circuitBreaker stops calling a failing backend: it opens after breakerThreshold consecutive
+// failures, and once breakerCooldown has passed lets a single trial call through, closing again
+// when it succeeds.
+type circuitBreaker struct {
+	name string
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	opens    atomic.Uint64
+	rejected atomic.Uint64
+	retries  atomic.Uint64
+}
+
+// allow reports whether a call can go through.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case models.BreakerOpen:
+		if time.Since(b.openedAt) < breakerCooldown {
+			break
+		}
+		b.state = models.BreakerHalfOpen
+		fallthrough
+	case models.BreakerHalfOpen:
+		if b.probing {
+			break
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+
+	b.rejected.Add(1)
+
+	return false
+}
+
+// record records the outcome of an allowed call.
+func (b *circuitBreaker) record(healthy bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if healthy {
+		b.state = models.BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == models.BreakerHalfOpen || b.failures >= breakerThreshold && b.state != models.BreakerOpen {
+		b.state = models.BreakerOpen
+		b.openedAt = time.Now()
+		b.opens.Add(1)
+	}
+}
+
+// abandon records an allowed call whose outcome is unknown, e.g. cancelled by the caller.
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Stats returns the state of the breaker.
+func (b *circuitBreaker) Stats() models.BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := models.BreakerStats{
+		Name:     b.name,
+		State:    b.state,
+		Failures: b.failures,
+		Opens:    b.opens.Load(),
+		Rejected: b.rejected.Load(),
+		Retries:  b.retries.Load(),
+	}
+	if stats.State == "" {
+		stats.State = models.BreakerClosed
+	}
+	if stats.State != models.BreakerClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+
+	return stats
+}
+
+// ResilientFilmSearch guards a FilmSearchRepository: every call gets a timeout, failed calls are
+// retried with jittered backoff, and a circuit breaker fails calls fast while the backend keeps
+// failing. All the calls are idempotent: documents are written and deleted by id.
+type ResilientFilmSearch struct {
+	repo     FilmSuggestSearchRepository
+	breaker  *circuitBreaker
+	observer SearchObserver
+}
+
+// FilmSuggestSearchRepository defines the search index of the films also suggesting them.
+type FilmSuggestSearchRepository interface {
+	FilmSearchRepository
+	Suggester
+}
+
+// SearchObserver is told about every call made to the backend, retries included, e.g. for
+// metrics.
+type SearchObserver interface {
+	ObserveSearch(backend, operation string, took time.Duration, err error)
+}
+
+// NewResilientFilmSearch ... The observer may be nil.
+func NewResilientFilmSearch(name string, repo FilmSuggestSearchRepository, observer SearchObserver) *ResilientFilmSearch {
+	return &ResilientFilmSearch{
+		repo:     repo,
+		breaker:  &circuitBreaker{name: name},
+		observer: observer,
+	}
+}
+
+// Index indexes the film.
+func (r *ResilientFilmSearch) Index(ctx context.Context, film models.Film) error {
+	return r.call(ctx, "index", writeTimeout, func(ctx context.Context) error {
+		return r.repo.Index(ctx, film)
+	})
+}
+
+// Delete removes the film from the index.
+func (r *ResilientFilmSearch) Delete(ctx context.Context, id string) error {
+	return r.call(ctx, "delete", writeTimeout, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+// Search searches the films.
+func (r *ResilientFilmSearch) Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error) {
+	var res models.FilmSearchResult
+
+	err := r.call(ctx, "search", searchTimeout, func(ctx context.Context) error {
+		var err error
+		res, err = r.repo.Search(ctx, p)
+		return err
+	})
+
+	return res, err
+}
+
+// Similar finds the films similar to the film with the id.
+func (r *ResilientFilmSearch) Similar(ctx context.Context, id string, limit int) ([]models.FilmHit, error) {
+	var hits []models.FilmHit
+
+	err := r.call(ctx, "similar", searchTimeout, func(ctx context.Context) error {
+		var err error
+		hits, err = r.repo.Similar(ctx, id, limit)
+		return err
+	})
+
+	return hits, err
+}
+
+// Suggest suggests the films whose name starts like q.
+func (r *ResilientFilmSearch) Suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error) {
+	var suggestions []models.Suggestion
+
+	err := r.call(ctx, "suggest", searchTimeout, func(ctx context.Context) error {
+		var err error
+		suggestions, err = r.repo.Suggest(ctx, q, limit)
+		return err
+	})
+
+	return suggestions, err
+}
+
+// Stats returns the state of the circuit breaker.
+func (r *ResilientFilmSearch) Stats() models.BreakerStats {
+	return r.breaker.Stats()
+}
+
+// call calls fn with a timeout, retrying it up to retryAttempts times while it fails and the
+// breaker allows it. Every attempt is reported to the observer as the operation.
+func (r *ResilientFilmSearch) call(ctx context.Context, operation string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		if !r.breaker.allow() {
+			return internal.NewErrorf(internal.ErrorCodeUnavailable, "%s circuit breaker is open", r.breaker.name)
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := fn(callCtx)
+		cancel()
+
+		if r.observer != nil {
+			r.observer.ObserveSearch(r.breaker.name, operation, time.Since(start), err)
+		}
+
+		// NOTE: The caller gave up, which says nothing about the backend.
+		if ctx.Err() != nil {
+			r.breaker.abandon()
+			if err == nil {
+				err = ctx.Err()
+			}
+			return err
+		}
+
+		retry := retryable(err)
+		r.breaker.record(!retry)
+
+		if !retry || attempt == retryAttempts {
+			return err
+		}
+
+		r.breaker.retries.Add(1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(attempt)):
+		}
+	}
+}
+
+// retryable reports whether err may be transient: transport errors and the unknown errors of the
+// backend, i.e. server errors, timeouts and throttling. Errors due to the request itself, e.g. the
+// client errors of the backend, aren't, and don't count as failures of the backend either.
+func retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var ierr *internal.Error
+	if errors.As(err, &ierr) {
+		return ierr.Code() == internal.ErrorCodeUnknown
+	}
+
+	return true
+}
+
+// jitter returns a random delay up to exponential backoff for the attempt, spreading the
+// retries of concurrent calls.
+func jitter(attempt int) time.Duration {
+	return time.Duration(rand.Int63n(int64(retryBaseDelay << (attempt - 1))))
+}
+
+// Check fails while the circuit breaker is open, see HealthCheck.
+func (r *ResilientFilmSearch) Check(_ context.Context) error {
+	if stats := r.breaker.Stats(); stats.State == models.BreakerOpen {
+		return internal.NewErrorf(internal.ErrorCodeUnavailable, "%s circuit breaker is open", stats.Name)
+	}
+
+	return nil
+}
+
+// ResilientActorSearch guards an ActorSearchRepository like ResilientFilmSearch. Both indices
+// live in the same backend, so the films' circuit breaker is shared.
+type ResilientActorSearch struct {
+	repo  ActorSuggestSearchRepository
+	films *ResilientFilmSearch
+}
+
+// ActorSuggestSearchRepository defines the search index of the actors also suggesting them.
+type ActorSuggestSearchRepository interface {
+	ActorSearchRepository
+	Suggester
+}
+
+// NewResilientActorSearch ...
+func NewResilientActorSearch(repo ActorSuggestSearchRepository, films *ResilientFilmSearch) *ResilientActorSearch {
+	return &ResilientActorSearch{
+		repo:  repo,
+		films: films,
+	}
+}
+
+// Index indexes the actor.
+func (r *ResilientActorSearch) Index(ctx context.Context, actor models.Actor) error {
+	return r.films.call(ctx, "index_actor", writeTimeout, func(ctx context.Context) error {
+		return r.repo.Index(ctx, actor)
+	})
+}
+
+// Delete removes the actor from the index.
+func (r *ResilientActorSearch) Delete(ctx context.Context, id string) error {
+	return r.films.call(ctx, "delete_actor", writeTimeout, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+// Search searches the actors.
+func (r *ResilientActorSearch) Search(ctx context.Context, p m.SearchActors) (models.ActorSearchResult, error) {
+	var res models.ActorSearchResult
+
+	err := r.films.call(ctx, "search_actors", searchTimeout, func(ctx context.Context) error {
+		var err error
+		res, err = r.repo.Search(ctx, p)
+		return err
+	})
+
+	return res, err
+}
+
+// Suggest suggests the actors whose name starts like q.
+func (r *ResilientActorSearch) Suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error) {
+	var suggestions []models.Suggestion
+
+	err := r.films.call(ctx, "suggest_actors", searchTimeout, func(ctx context.Context) error {
+		var err error
+		suggestions, err = r.repo.Suggest(ctx, q, limit)
+		return err
+	})
+
+	return suggestions, err
+}
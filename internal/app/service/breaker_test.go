@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// trip records breakerThreshold failures, opening b.
+func trip(b *circuitBreaker) {
+	for i := 0; i < breakerThreshold; i++ {
+		b.allow()
+		b.record(false)
+	}
+}
+
+// cool makes the cooldown of the open b pass.
+func cool(b *circuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.openedAt = time.Now().Add(-breakerCooldown)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	testCases := []struct {
+		name     string
+		f        func(b *circuitBreaker)
+		allow    bool
+		state    string
+		opens    uint64
+		rejected uint64
+	}{
+		{
+			name:  "closed",
+			f:     func(b *circuitBreaker) {},
+			allow: true,
+			state: models.BreakerClosed,
+		},
+		{
+			name: "closed below the threshold",
+			f: func(b *circuitBreaker) {
+				for i := 0; i < breakerThreshold-1; i++ {
+					b.allow()
+					b.record(false)
+				}
+			},
+			allow: true,
+			state: models.BreakerClosed,
+		},
+		{
+			name: "success resets the failures",
+			f: func(b *circuitBreaker) {
+				for i := 0; i < breakerThreshold-1; i++ {
+					b.allow()
+					b.record(false)
+				}
+				b.allow()
+				b.record(true)
+				b.allow()
+				b.record(false)
+			},
+			allow: true,
+			state: models.BreakerClosed,
+		},
+		{
+			name:     "open at the threshold",
+			f:        trip,
+			allow:    false,
+			state:    models.BreakerOpen,
+			opens:    1,
+			rejected: 1,
+		},
+		{
+			name: "half open after the cooldown",
+			f: func(b *circuitBreaker) {
+				trip(b)
+				cool(b)
+			},
+			allow: true,
+			state: models.BreakerHalfOpen,
+			opens: 1,
+		},
+		{
+			name: "single probe while half open",
+			f: func(b *circuitBreaker) {
+				trip(b)
+				cool(b)
+				b.allow()
+			},
+			allow:    false,
+			state:    models.BreakerHalfOpen,
+			opens:    1,
+			rejected: 1,
+		},
+		{
+			name: "closed after a successful probe",
+			f: func(b *circuitBreaker) {
+				trip(b)
+				cool(b)
+				b.allow()
+				b.record(true)
+			},
+			allow: true,
+			state: models.BreakerClosed,
+			opens: 1,
+		},
+		{
+			name: "open again after a failed probe",
+			f: func(b *circuitBreaker) {
+				trip(b)
+				cool(b)
+				b.allow()
+				b.record(false)
+			},
+			allow:    false,
+			state:    models.BreakerOpen,
+			opens:    2,
+			rejected: 1,
+		},
+		{
+			name: "abandoned probe lets another one through",
+			f: func(b *circuitBreaker) {
+				trip(b)
+				cool(b)
+				b.allow()
+				b.abandon()
+			},
+			allow: true,
+			state: models.BreakerHalfOpen,
+			opens: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &circuitBreaker{name: "test"}
+			tc.f(b)
+
+			assert.Equal(t, tc.allow, b.allow())
+
+			stats := b.Stats()
+			assert.Equal(t, tc.state, stats.State)
+			assert.Equal(t, tc.opens, stats.Opens)
+			assert.Equal(t, tc.rejected, stats.Rejected)
+		})
+	}
+}
+
+func TestResilientFilmSearch_call(t *testing.T) {
+	testCases := []struct {
+		name     string
+		errs     []error
+		isErr    bool
+		calls    int
+		failures int
+	}{
+		{
+			name:  "success",
+			errs:  []error{nil},
+			calls: 1,
+		},
+		{
+			name:     "transport error retried",
+			errs:     []error{errors.New("connection refused"), nil},
+			calls:    2,
+			failures: 0,
+		},
+		{
+			name: "server error retried up to the attempts",
+			errs: []error{
+				internal.NewErrorf(internal.ErrorCodeUnknown, "SearchRequest.Do 503"),
+				internal.NewErrorf(internal.ErrorCodeUnknown, "SearchRequest.Do 503"),
+				internal.NewErrorf(internal.ErrorCodeUnknown, "SearchRequest.Do 503"),
+			},
+			isErr:    true,
+			calls:    retryAttempts,
+			failures: retryAttempts,
+		},
+		{
+			name:  "client error not retried",
+			errs:  []error{internal.NewErrorf(internal.ErrorCodeInvalidArgument, "SearchRequest.Do 400")},
+			isErr: true,
+			calls: 1,
+		},
+		{
+			name:  "not found not retried",
+			errs:  []error{internal.NewErrorf(internal.ErrorCodeNotFound, "film not found")},
+			isErr: true,
+			calls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewResilientFilmSearch("test", nil, nil)
+
+			calls := 0
+			err := r.call(context.Background(), "search", time.Second, func(ctx context.Context) error {
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+
+			assert.Equal(t, tc.isErr, err != nil)
+			assert.Equal(t, tc.calls, calls)
+			assert.Equal(t, tc.failures, r.Stats().Failures)
+			assert.Equal(t, uint64(tc.calls-1), r.Stats().Retries)
+		})
+	}
+}
+
+func TestResilientFilmSearch_callOpen(t *testing.T) {
+	r := NewResilientFilmSearch("test", nil, nil)
+	trip(r.breaker)
+
+	err := r.call(context.Background(), "search", time.Second, func(ctx context.Context) error {
+		t.Fatal("called while the breaker is open")
+		return nil
+	})
+
+	var ierr *internal.Error
+	if assert.True(t, errors.As(err, &ierr)) {
+		assert.Equal(t, internal.ErrorCodeUnavailable, ierr.Code())
+	}
+}
+
+func TestResilientFilmSearch_callClientErrors(t *testing.T) {
+	r := NewResilientFilmSearch("test", nil, nil)
+
+	for i := 0; i < 2*breakerThreshold; i++ {
+		_ = r.call(context.Background(), "search", time.Second, func(ctx context.Context) error {
+			return internal.NewErrorf(internal.ErrorCodeInvalidArgument, "SearchRequest.Do 400")
+		})
+	}
+
+	assert.Equal(t, models.BreakerClosed, r.Stats().State)
+	assert.Equal(t, 0, r.Stats().Failures)
+}
+
+func TestResilientFilmSearch_callCancelled(t *testing.T) {
+	r := NewResilientFilmSearch("test", nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := r.call(ctx, "search", time.Second, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, r.Stats().Failures)
+}
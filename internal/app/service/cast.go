@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// CastRepository defines the datastore handling the links between Films and Actors.
+type CastRepository interface {
+	Create(ctx context.Context, filmId string, c m.CreateCast) (models.CastMember, error)
+	Delete(ctx context.Context, filmId, actorId string) error
+	Update(ctx context.Context, filmId, actorId string, c m.UpdateCast) error
+	FindByFilm(ctx context.Context, filmId string) ([]models.CastMember, error)
+	FindByActor(ctx context.Context, actorId string) ([]models.Role, error)
+}
+
+// CastService defines the application service in charge of interacting with Film casts.
+type CastService struct {
+	repo CastRepository
+}
+
+// NewCastService
+func NewCastService(repo CastRepository) *CastService {
+	return &CastService{
+		repo: repo,
+	}
+}
+
+// Create adds an actor to the cast of a film.
+func (s *CastService) Create(ctx context.Context, filmId string, c m.CreateCast) (models.CastMember, error) {
+	ctx, span := tracer.Start(ctx, "CastService.Create")
+	defer span.End()
+
+	if err := c.Validate(); err != nil {
+		return models.CastMember{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate cast")
+	}
+
+	cm, err := s.repo.Create(ctx, filmId, c)
+	if err != nil {
+		return models.CastMember{}, fmt.Errorf("repo create: %w", err)
+	}
+
+	return cm, nil
+}
+
+// Delete removes an actor from the cast of a film.
+func (s *CastService) Delete(ctx context.Context, filmId, actorId string) error {
+	ctx, span := tracer.Start(ctx, "CastService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, filmId, actorId); err != nil {
+		return fmt.Errorf("repo delete: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates the role of an actor in a film.
+func (s *CastService) Update(ctx context.Context, filmId, actorId string, c m.UpdateCast) error {
+	ctx, span := tracer.Start(ctx, "CastService.Update")
+	defer span.End()
+
+	if err := c.Validate(); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate cast")
+	}
+
+	if err := s.repo.Update(ctx, filmId, actorId, c); err != nil {
+		return fmt.Errorf("repo update: %w", err)
+	}
+
+	return nil
+}
+
+// FilmCast gets the cast of an existing Film.
+func (s *CastService) FilmCast(ctx context.Context, filmId string) ([]models.CastMember, error) {
+	ctx, span := tracer.Start(ctx, "CastService.FilmCast")
+	defer span.End()
+
+	cast, err := s.repo.FindByFilm(ctx, filmId)
+	if err != nil {
+		return nil, fmt.Errorf("repo find: %w", err)
+	}
+
+	return cast, nil
+}
+
+// ActorFilms gets the films an existing Actor played in.
+func (s *CastService) ActorFilms(ctx context.Context, actorId string) ([]models.Role, error) {
+	ctx, span := tracer.Start(ctx, "CastService.ActorFilms")
+	defer span.End()
+
+	roles, err := s.repo.FindByActor(ctx, actorId)
+	if err != nil {
+		return nil, fmt.Errorf("repo find: %w", err)
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// FailoverSearch searches films with the primary search repository and falls back to another
+// one when the primary fails, the result names the backend which served it. The primary is
+// expected to fail fast while it's down, see ResilientFilmSearch. Writes only go to the primary,
+// the fallback is expected to be kept up to date by other means.
+type FailoverSearch struct {
+	primary  FilmSearchRepository
+	fallback FilmSearchRepository
+	logger   *zap.Logger
+}
+
+// NewFailoverSearch ...
+func NewFailoverSearch(primary, fallback FilmSearchRepository, logger *zap.Logger) *FailoverSearch {
+	return &FailoverSearch{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+// Index indexes the film with the primary.
+func (f *FailoverSearch) Index(ctx context.Context, film models.Film) error {
+	return f.primary.Index(ctx, film)
+}
+
+// Delete removes the film from the primary.
+func (f *FailoverSearch) Delete(ctx context.Context, id string) error {
+	return f.primary.Delete(ctx, id)
+}
+
+// Search searches with the primary, and with the fallback when it fails. Cancelled searches and
+// invalid ones aren't retried.
+func (f *FailoverSearch) Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "FailoverSearch.Search")
+	defer span.End()
+
+	res, err := f.primary.Search(ctx, p)
+	if err == nil {
+		return res, nil
+	}
+
+	if !fallible(ctx, err) {
+		return models.FilmSearchResult{}, err
+	}
+
+	var ierr *internal.Error
+	if !errors.As(err, &ierr) || ierr.Code() != internal.ErrorCodeUnavailable {
+		f.logger.Warn("primary search failed, falling back", zap.Error(err))
+	}
+
+	return f.fallback.Search(ctx, p)
+}
+
+// Similar finds the similar films with the primary, and with the fallback when it fails.
+func (f *FailoverSearch) Similar(ctx context.Context, id string, limit int) ([]models.FilmHit, error) {
+	ctx, span := tracer.Start(ctx, "FailoverSearch.Similar")
+	defer span.End()
+
+	hits, err := f.primary.Similar(ctx, id, limit)
+	if err == nil || !fallible(ctx, err) {
+		return hits, err
+	}
+
+	return f.fallback.Similar(ctx, id, limit)
+}
+
+// fallible reports whether the fallback may succeed where the primary failed with err: not when
+// the caller gave up, nor when the request itself is invalid.
+func fallible(ctx context.Context, err error) bool {
+	if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+		return false
+	}
+
+	var ierr *internal.Error
+	return !errors.As(err, &ierr) || ierr.Code() != internal.ErrorCodeInvalidArgument
+}
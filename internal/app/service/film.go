@@ -4,34 +4,35 @@ import (
 	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel"
+
 	"filmoteka/internal"
 	"filmoteka/internal/app/models"
 	m "filmoteka/internal/restapi/models"
 )
 
+var tracer = otel.Tracer("filmoteka/internal/app/service")
+
 // FilmRepository defines the datastore handling Film records.
 type FilmRepository interface {
-	Create(f m.CreateFilm) (models.Film, error)
-	Delete(id string) error
-	FindAll() ([]models.Film, error)
-	Find(id string) (models.Film, error)
-	Update(id string, f m.UpdateFilm) error
+	Create(ctx context.Context, f m.CreateFilm) (models.Film, error)
+	Delete(ctx context.Context, id string, version int) error
+	FindAll(ctx context.Context, p m.ListFilms) ([]models.Film, int, error)
+	Find(ctx context.Context, id string) (models.Film, error)
+	Update(ctx context.Context, id string, f m.UpdateFilm, version int) (int, error)
+	Patch(ctx context.Context, id string, f m.PatchFilm, version int) error
 }
 
 // FilmSearchRepository defines the datastore handling persisting Searchable Film records.
 type FilmSearchRepository interface {
 	Delete(ctx context.Context, id string) error
 	Index(ctx context.Context, film models.Film) error
-	Search(
-		ctx context.Context,
-		name *string,
-		description *string,
-		releaseYear *uint16,
-		rating *float32,
-	) ([]models.Film, error)
+	Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error)
+	Similar(ctx context.Context, id string, limit int) ([]models.FilmHit, error)
 }
 
-// FilmService defines the application service in charge of interacting with Tasks.
+// FilmService defines the application service in charge of interacting with Tasks. Changes reach
+// the search index through the outbox written by the repository, see SearchRelay.
 type FilmService struct {
 	repo   FilmRepository
 	search FilmSearchRepository
@@ -45,52 +46,81 @@ func NewFilmService(repo FilmRepository, search FilmSearchRepository) *FilmServi
 	}
 }
 
-// Search gets all existing Films from the datastore.
-func (s *FilmService) Search(
-	ctx context.Context,
-	name string,
-	description string,
-	releaseYear uint16,
-	rating float32,
-) ([]models.Film, error) {
-	films, err := s.search.Search(ctx, &name, &description, &releaseYear, &rating)
+// Search gets a page of the Films matching p from the search index and the total number of matches.
+func (s *FilmService) Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Search")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate search")
+	}
+
+	res, err := s.search.Search(ctx, p)
+	if err != nil {
+		return models.FilmSearchResult{}, fmt.Errorf("search: %w", err)
+	}
+
+	return res, nil
+}
+
+// Similar gets the Films most similar to the Film with the id from the search index.
+func (s *FilmService) Similar(ctx context.Context, id string, p m.SimilarFilms) ([]models.FilmHit, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Similar")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate similar")
+	}
+
+	// NOTE: The index finds nothing for missing films, the datastore tells them apart.
+	if _, err := s.repo.Find(ctx, id); err != nil {
+		return nil, fmt.Errorf("repo find: %w", err)
+	}
+
+	hits, err := s.search.Similar(ctx, id, p.Limit)
 	if err != nil {
-		return nil, fmt.Errorf("search: %w", err)
+		return nil, fmt.Errorf("search similar: %w", err)
 	}
 
-	return films, nil
+	return hits, nil
 }
 
 // Create stores a new record.
 func (s *FilmService) Create(ctx context.Context, f m.CreateFilm) (models.Film, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Create")
+	defer span.End()
+
 	if err := f.Validate(); err != nil {
 		return models.Film{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate film")
 	}
 
-	film, err := s.repo.Create(f)
+	film, err := s.repo.Create(ctx, f)
 	if err != nil {
 		return models.Film{}, fmt.Errorf("repo create: %w", err)
 	}
 
-	_ = s.search.Index(ctx, film) // Ignoring errors on purpose
-
 	return film, nil
 }
 
-// Delete removes an existing Film from the datastore.
-func (s *FilmService) Delete(ctx context.Context, id string) error {
-	if err := s.repo.Delete(id); err != nil {
+// Delete removes an existing Film from the datastore, when version is not 0 only if it's the
+// current version.
+func (s *FilmService) Delete(ctx context.Context, id string, version int) error {
+	ctx, span := tracer.Start(ctx, "FilmService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id, version); err != nil {
 		return fmt.Errorf("repo delete: %w", err)
 	}
 
-	_ = s.search.Delete(ctx, id) // Ignoring errors on purpose
-
 	return nil
 }
 
 // Find gets an existing Film from the datastore.
-func (s *FilmService) Find(id string) (models.Film, error) {
-	task, err := s.repo.Find(id)
+func (s *FilmService) Find(ctx context.Context, id string) (models.Film, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Find")
+	defer span.End()
+
+	task, err := s.repo.Find(ctx, id)
 	if err != nil {
 		return models.Film{}, fmt.Errorf("repo find: %w", err)
 	}
@@ -98,29 +128,114 @@ func (s *FilmService) Find(id string) (models.Film, error) {
 	return task, nil
 }
 
-// FindAll gets all existing Films from the datastore.
-func (s *FilmService) FindAll() ([]models.Film, error) {
-	films, err := s.repo.FindAll()
+// FindAll gets a page of existing Films from the datastore and the total number of matches.
+func (s *FilmService) FindAll(ctx context.Context, p m.ListFilms) ([]models.Film, int, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.FindAll")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate list")
+	}
+
+	films, total, err := s.repo.FindAll(ctx, p)
 	if err != nil {
-		return nil, fmt.Errorf("repo find: %w", err)
+		return nil, 0, fmt.Errorf("repo find: %w", err)
 	}
 
-	return films, nil
+	return films, total, nil
 }
 
-// Update updates an existing Film in the datastore.
-func (s *FilmService) Update(ctx context.Context, id string, f m.UpdateFilm) error {
+// Update updates an existing Film in the datastore, when version is not 0 only if it's the
+// current version, and returns its new version.
+func (s *FilmService) Update(ctx context.Context, id string, f m.UpdateFilm, version int) (int, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Update")
+	defer span.End()
+
 	if err := f.Validate(); err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate film")
+		return 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate film")
 	}
 
-	if err := s.repo.Update(id, f); err != nil {
-		return fmt.Errorf("repo update: %w", err)
+	updated, err := s.repo.Update(ctx, id, f, version)
+	if err != nil {
+		return 0, fmt.Errorf("repo update: %w", err)
 	}
 
-	film, err := s.repo.Find(id)
-	if err == nil {
-		_ = s.search.Index(ctx, film) // Ignoring errors on purpose
+	return updated, nil
+}
+
+// Patch applies a partial update to an existing Film, only the changed fields are written. When
+// version is not 0 the Film is only updated if it's the current version, otherwise the patch is
+// applied again when the Film changes meanwhile, see retryConflicts.
+func (s *FilmService) Patch(ctx context.Context, id string, p m.Patch, version int) (models.Film, error) {
+	ctx, span := tracer.Start(ctx, "FilmService.Patch")
+	defer span.End()
+
+	var film models.Film
+	err := retryConflicts(version, func() error {
+		var err error
+		film, err = s.patch(ctx, id, p, version)
+		return err
+	})
+
+	return film, err
+}
+
+// patch applies p to the Film as currently stored, the changes are only written if it's still the
+// version read.
+func (s *FilmService) patch(ctx context.Context, id string, p m.Patch, version int) (models.Film, error) {
+	film, err := s.repo.Find(ctx, id)
+	if err != nil {
+		return models.Film{}, fmt.Errorf("repo find: %w", err)
 	}
-	return nil
+
+	if version != 0 && version != film.Version {
+		return models.Film{}, internal.NewErrorf(internal.ErrorCodePreconditionFailed, "version %d is not current", version)
+	}
+
+	current := m.UpdateFilm{
+		Name:        film.Name,
+		Description: film.Description,
+		ReleaseYear: film.ReleaseYear,
+		Rating:      film.Rating,
+	}
+
+	var patched m.UpdateFilm
+	if err := applyPatch(p, current, &patched); err != nil {
+		return models.Film{}, err
+	}
+
+	if err := patched.Validate(); err != nil {
+		return models.Film{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate film")
+	}
+
+	var changes m.PatchFilm
+	if patched.Name != current.Name {
+		changes.Name = &patched.Name
+	}
+	if patched.Description != current.Description {
+		changes.Description = &patched.Description
+	}
+	if patched.ReleaseYear != current.ReleaseYear {
+		changes.ReleaseYear = &patched.ReleaseYear
+	}
+	if patched.Rating != current.Rating {
+		changes.Rating = &patched.Rating
+	}
+
+	if changes == (m.PatchFilm{}) {
+		return film, nil
+	}
+
+	// NOTE: Always conditional, the Film may have changed since it was read, see Patch.
+	if err := s.repo.Patch(ctx, id, changes, film.Version); err != nil {
+		return models.Film{}, fmt.Errorf("repo patch: %w", err)
+	}
+
+	film.Name = patched.Name
+	film.Description = patched.Description
+	film.ReleaseYear = patched.ReleaseYear
+	film.Rating = patched.Rating
+	film.Version++
+
+	return film, nil
 }
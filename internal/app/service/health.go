@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"filmoteka/internal/app/models"
+)
+
+// healthCheckTimeout bounds every dependency check.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck checks a dependency of the service.
+type HealthCheck struct {
+	Name string
+	// Critical dependencies failing make the service unready, others only degrade it, e.g.
+	// searches are served by Postgres while Elasticsearch is down.
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// HealthService reports the readiness of the service by checking its dependencies.
+type HealthService struct {
+	checks       []HealthCheck
+	shuttingDown atomic.Bool
+}
+
+// NewHealthService ...
+func NewHealthService(checks ...HealthCheck) *HealthService {
+	return &HealthService{
+		checks: checks,
+	}
+}
+
+// Shutdown makes the service report it's not ready from now on, so that no new traffic is routed
+// to it while in-flight requests complete.
+func (s *HealthService) Shutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready checks every dependency concurrently, each within healthCheckTimeout.
+func (s *HealthService) Ready(ctx context.Context) models.Health {
+	if s.shuttingDown.Load() {
+		return models.Health{Status: models.HealthShuttingDown}
+	}
+
+	health := models.Health{
+		Status: models.HealthOK,
+		Checks: make(map[string]models.HealthCheck, len(s.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, c := range s.checks {
+		wg.Add(1)
+
+		go func(c HealthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.Check(ctx)
+
+			res := models.HealthCheck{
+				Status:    models.HealthOK,
+				Critical:  c.Critical,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				res.Status = models.HealthFailing
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			health.Checks[c.Name] = res
+
+			switch {
+			case err == nil:
+			case c.Critical:
+				health.Status = models.HealthFailing
+			case health.Status == models.HealthOK:
+				health.Status = models.HealthDegraded
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return health
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"filmoteka/internal"
+	m "filmoteka/internal/restapi/models"
+)
+
+// applyPatch applies p to the JSON representation of current and decodes the result into
+// patched. Fields unknown to patched are rejected.
+func applyPatch(p m.Patch, current interface{}, patched interface{}) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.Marshal")
+	}
+
+	res, err := p.Apply(doc)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "apply patch")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(res))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patched); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "apply patch")
+	}
+
+	return nil
+}
+
+// patchAttempts is how many times a patch is applied at most while the record keeps changing
+// between its read and its write.
+const patchAttempts = 3
+
+// retryConflicts calls fn, which reads, patches and conditionally writes a record, again while
+// the record changed meanwhile. Only when version is 0: the client didn't ask for a version, so
+// a change made meanwhile, e.g. to other fields, is not a conflict.
+func retryConflicts(version int, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+
+		var ierr *internal.Error
+		if version != 0 || attempt == patchAttempts ||
+			!errors.As(err, &ierr) || ierr.Code() != internal.ErrorCodePreconditionFailed {
+			return err
+		}
+	}
+}
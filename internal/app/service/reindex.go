@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// FilmSource defines the datastore the search index is rebuilt from.
+type FilmSource interface {
+	Walk(ctx context.Context, fn func(models.Film) error) error
+	Find(ctx context.Context, id string) (models.Film, error)
+}
+
+// FilmChanges defines the datastore recording the changes made to films, see OutboxRepository.
+// The changes aren't purged while it's locked.
+type FilmChanges interface {
+	Lock(ctx context.Context) (func() error, error)
+	Position(ctx context.Context) (int64, error)
+	ChangedFilms(ctx context.Context, position int64) ([]string, error)
+}
+
+// FilmIndexer defines the search datastore handling versioned indices behind an alias.
+type FilmIndexer interface {
+	CreateIndex(ctx context.Context) (string, error)
+	BulkIndex(ctx context.Context, index string, films []models.Film) error
+	BulkDelete(ctx context.Context, index string, ids []string) error
+	Refresh(ctx context.Context, index string) error
+	SwapAlias(ctx context.Context, index string) ([]string, error)
+	DeleteIndex(ctx context.Context, indices ...string) error
+}
+
+// ReindexService rebuilds the films search index from the database.
+type ReindexService struct {
+	films   FilmSource
+	changes FilmChanges
+	indexer FilmIndexer
+	logger  *zap.Logger
+}
+
+// NewReindexService ...
+func NewReindexService(films FilmSource, changes FilmChanges, indexer FilmIndexer, logger *zap.Logger) *ReindexService {
+	return &ReindexService{
+		films:   films,
+		changes: changes,
+		indexer: indexer,
+		logger:  logger,
+	}
+}
+
+// Reindex indexes all films into a new index, batch films per bulk request, and then swaps the
+// alias searches use to it. Searches keep using the previous index until the new one is
+// complete, which is then deleted unless keepOld is set. It returns the new index and the
+// number of films indexed.
+//
+// The relay delivers the changes made while the index is built to the previous index, they are
+// replayed into the new index before the swap, and once more after it for the changes delivered
+// in between. The outbox is locked meanwhile, so that the delivered events aren't purged before
+// they are replayed, and it fails when another reindex holds the lock.
+func (s *ReindexService) Reindex(ctx context.Context, batch int, keepOld bool) (string, int, error) {
+	ctx, span := tracer.Start(ctx, "ReindexService.Reindex")
+	defer span.End()
+
+	unlock, err := s.changes.Lock(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("outbox lock: %w", err)
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			s.logger.Error("Unlocking outbox failed", zap.Error(err))
+		}
+	}()
+
+	// NOTE: Taken before the films are read, so that every change they miss comes after it.
+	position, err := s.changes.Position(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("outbox position: %w", err)
+	}
+
+	index, err := s.indexer.CreateIndex(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("create index: %w", err)
+	}
+
+	s.logger.Info("Index created", zap.String("index", index))
+
+	count, err := s.fill(ctx, index, batch)
+	if err == nil {
+		err = s.replay(ctx, index, position, batch)
+	}
+	if err != nil {
+		// NOTE: The alias still points to the previous index, the new one is of no use.
+		if derr := s.indexer.DeleteIndex(ctx, index); derr != nil {
+			s.logger.Error("Deleting incomplete index failed", zap.String("index", index), zap.Error(derr))
+		}
+
+		return "", 0, err
+	}
+
+	old, err := s.indexer.SwapAlias(ctx, index)
+	if err != nil {
+		return "", 0, fmt.Errorf("swap alias: %w", err)
+	}
+
+	s.logger.Info("Alias swapped", zap.String("index", index), zap.Strings("previous", old))
+
+	if err := s.replay(ctx, index, position, batch); err != nil {
+		return "", 0, fmt.Errorf("after swap: %w", err)
+	}
+
+	if !keepOld {
+		if err := s.indexer.DeleteIndex(ctx, old...); err != nil {
+			return "", 0, fmt.Errorf("delete previous index: %w", err)
+		}
+	}
+
+	return index, count, nil
+}
+
+// fill streams all films into index and makes them searchable.
+func (s *ReindexService) fill(ctx context.Context, index string, batch int) (int, error) {
+	var count int
+
+	films := make([]models.Film, 0, batch)
+	flush := func() error {
+		if len(films) == 0 {
+			return nil
+		}
+		if err := s.indexer.BulkIndex(ctx, index, films); err != nil {
+			return fmt.Errorf("bulk index: %w", err)
+		}
+
+		count += len(films)
+		films = films[:0]
+
+		s.logger.Info("Films indexed", zap.Int("count", count))
+
+		return nil
+	}
+
+	if err := s.films.Walk(ctx, func(f models.Film) error {
+		films = append(films, f)
+		if len(films) < batch {
+			return nil
+		}
+		return flush()
+	}); err != nil {
+		return 0, fmt.Errorf("repo walk: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	if err := s.indexer.Refresh(ctx, index); err != nil {
+		return 0, fmt.Errorf("refresh index: %w", err)
+	}
+
+	return count, nil
+}
+
+// replay brings the films changed from the position on up to date in index, deleted films are
+// removed from it, and makes them searchable.
+func (s *ReindexService) replay(ctx context.Context, index string, position int64, batch int) error {
+	ids, err := s.changes.ChangedFilms(ctx, position)
+	if err != nil {
+		return fmt.Errorf("changed films: %w", err)
+	}
+
+	for len(ids) > 0 {
+		n := min(batch, len(ids))
+
+		var (
+			films   []models.Film
+			deleted []string
+		)
+
+		for _, id := range ids[:n] {
+			f, err := s.films.Find(ctx, id)
+			if err != nil {
+				var ierr *internal.Error
+				if !errors.As(err, &ierr) || ierr.Code() != internal.ErrorCodeNotFound {
+					return fmt.Errorf("repo find: %w", err)
+				}
+				deleted = append(deleted, id)
+				continue
+			}
+			films = append(films, f)
+		}
+
+		if len(films) > 0 {
+			if err := s.indexer.BulkIndex(ctx, index, films); err != nil {
+				return fmt.Errorf("bulk index: %w", err)
+			}
+		}
+		if len(deleted) > 0 {
+			if err := s.indexer.BulkDelete(ctx, index, deleted); err != nil {
+				return fmt.Errorf("bulk delete: %w", err)
+			}
+		}
+
+		s.logger.Info("Changes replayed", zap.Int("indexed", len(films)), zap.Int("deleted", len(deleted)))
+
+		ids = ids[n:]
+	}
+
+	if err := s.indexer.Refresh(ctx, index); err != nil {
+		return fmt.Errorf("refresh index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// reindexStore is the database and the search indices of a reindex, films change while they are
+// walked.
+type reindexStore struct {
+	films   map[int]models.Film
+	changed []string
+	// onWalk changes the films once the walk read them.
+	onWalk func(s *reindexStore)
+
+	indices map[string]map[int]models.Film
+	alias   string
+
+	locked bool
+}
+
+func (s *reindexStore) Lock(context.Context) (func() error, error) {
+	if s.locked {
+		return nil, internal.NewErrorf(internal.ErrorCodeUnavailable, "locked")
+	}
+	s.locked = true
+
+	return func() error {
+		s.locked = false
+		return nil
+	}, nil
+}
+
+func (s *reindexStore) Walk(_ context.Context, fn func(models.Film) error) error {
+	films := make([]models.Film, 0, len(s.films))
+	for _, f := range s.films {
+		films = append(films, f)
+	}
+	sort.Slice(films, func(i, j int) bool { return films[i].Id < films[j].Id })
+
+	s.onWalk(s)
+
+	for _, f := range films {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *reindexStore) Find(_ context.Context, id string) (models.Film, error) {
+	n, _ := strconv.Atoi(id)
+	f, ok := s.films[n]
+	if !ok {
+		return models.Film{}, internal.NewErrorf(internal.ErrorCodeNotFound, "film not found")
+	}
+
+	return f, nil
+}
+
+func (s *reindexStore) Position(context.Context) (int64, error) {
+	return 42, nil
+}
+
+func (s *reindexStore) ChangedFilms(_ context.Context, position int64) ([]string, error) {
+	if position != 42 {
+		return nil, internal.NewErrorf(internal.ErrorCodeUnknown, "unexpected position %d", position)
+	}
+
+	return s.changed, nil
+}
+
+func (s *reindexStore) CreateIndex(context.Context) (string, error) {
+	s.indices["films_2"] = map[int]models.Film{}
+	return "films_2", nil
+}
+
+func (s *reindexStore) BulkIndex(_ context.Context, index string, films []models.Film) error {
+	for _, f := range films {
+		s.indices[index][f.Id] = f
+	}
+
+	return nil
+}
+
+func (s *reindexStore) BulkDelete(_ context.Context, index string, ids []string) error {
+	for _, id := range ids {
+		n, _ := strconv.Atoi(id)
+		delete(s.indices[index], n)
+	}
+
+	return nil
+}
+
+func (s *reindexStore) Refresh(context.Context, string) error {
+	return nil
+}
+
+func (s *reindexStore) SwapAlias(_ context.Context, index string) ([]string, error) {
+	old := s.alias
+	s.alias = index
+
+	return []string{old}, nil
+}
+
+func (s *reindexStore) DeleteIndex(_ context.Context, indices ...string) error {
+	for _, index := range indices {
+		delete(s.indices, index)
+	}
+
+	return nil
+}
+
+func TestReindexService_Reindex(t *testing.T) {
+	store := &reindexStore{
+		films: map[int]models.Film{
+			1: {Id: 1, Name: "Film 1", Actors: []string{"Actor 1"}},
+			2: {Id: 2, Name: "Film 2"},
+			3: {Id: 3, Name: "Film 3", Actors: []string{"Actor 1", "Actor 2"}},
+		},
+		onWalk: func(s *reindexStore) {
+			s.films[1] = models.Film{Id: 1, Name: "Film 1 edited", Version: 2, Actors: []string{"Actor 2"}}
+			delete(s.films, 2)
+			s.films[4] = models.Film{Id: 4, Name: "Film 4"}
+			s.changed = []string{"1", "2", "4"}
+		},
+		// NOTE: Indexed by an older mapping version, without the cast.
+		indices: map[string]map[int]models.Film{"films_1": {
+			1: {Id: 1, Name: "Film 1"},
+			2: {Id: 2, Name: "Film 2"},
+			3: {Id: 3, Name: "Film 3"},
+		}},
+		alias: "films_1",
+	}
+
+	svc := NewReindexService(store, store, store, zap.NewNop())
+
+	index, count, err := svc.Reindex(context.Background(), 2, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "films_2", index)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "films_2", store.alias)
+	assert.NotContains(t, store.indices, "films_1")
+	assert.Equal(t, store.films, store.indices["films_2"])
+	assert.False(t, store.locked)
+}
+
+func TestReindexService_ReindexLocked(t *testing.T) {
+	store := &reindexStore{
+		films:   map[int]models.Film{1: {Id: 1, Name: "Film 1"}},
+		onWalk:  func(s *reindexStore) {},
+		indices: map[string]map[int]models.Film{"films_1": {}},
+		alias:   "films_1",
+		locked:  true,
+	}
+
+	svc := NewReindexService(store, store, store, zap.NewNop())
+
+	_, _, err := svc.Reindex(context.Background(), 2, false)
+
+	var ierr *internal.Error
+	if assert.True(t, errors.As(err, &ierr)) {
+		assert.Equal(t, internal.ErrorCodeUnavailable, ierr.Code())
+	}
+	assert.Equal(t, "films_1", store.alias)
+	assert.NotContains(t, store.indices, "films_2")
+}
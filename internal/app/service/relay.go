@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+const (
+	relayInterval   = time.Second
+	relayBatch      = 50
+	relayLease      = time.Minute
+	relayMinBackoff = time.Second
+	relayMaxBackoff = 5 * time.Minute
+
+	// relayRetention is how long delivered events are kept, longer while the search index is
+	// rebuilt, see ReindexService.
+	relayRetention     = 24 * time.Hour
+	relayPurgeInterval = time.Hour
+)
+
+// OutboxRepository defines the datastore handling the changes waiting to be delivered to the
+// search index.
+type OutboxRepository interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	Delivered(ctx context.Context, id int64) error
+	Failed(ctx context.Context, id int64, cause string, retryIn time.Duration) error
+	Dead(ctx context.Context, id int64, cause string) error
+	Stats(ctx context.Context) (models.OutboxStats, error)
+	Purge(ctx context.Context, retention time.Duration) error
+}
+
+// SearchRelay delivers the outbox events to the search index, retrying failed deliveries with
+// exponential backoff. Events failing maxAttempts deliveries are parked as dead.
+type SearchRelay struct {
+	outbox       OutboxRepository
+	maxAttempts  int
+	films        FilmRepository
+	search       FilmSearchRepository
+	actors       ActorRepository
+	actorsSearch ActorSearchRepository
+	logger       *zap.Logger
+
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// NewSearchRelay ...
+func NewSearchRelay(outbox OutboxRepository, maxAttempts int, films FilmRepository, search FilmSearchRepository,
+	actors ActorRepository, actorsSearch ActorSearchRepository, logger *zap.Logger) *SearchRelay {
+	return &SearchRelay{
+		outbox:       outbox,
+		maxAttempts:  maxAttempts,
+		films:        films,
+		search:       search,
+		actors:       actors,
+		actorsSearch: actorsSearch,
+		logger:       logger,
+	}
+}
+
+// Run delivers the outbox, and purges the events delivered long ago, until ctx is cancelled.
+func (s *SearchRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(relayPurgeInterval)
+	defer purge.Stop()
+
+	for {
+		// NOTE: Full batches mean there is a backlog, keep going without waiting for the ticker.
+		for s.relay(ctx) == relayBatch && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-purge.C:
+			if err := s.outbox.Purge(ctx, relayRetention); err != nil {
+				s.logger.Error("Purging outbox failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Stats returns the state of the outbox and the deliveries since the relay started.
+func (s *SearchRelay) Stats(ctx context.Context) (models.RelayStats, error) {
+	outbox, err := s.outbox.Stats(ctx)
+	if err != nil {
+		return models.RelayStats{}, fmt.Errorf("outbox stats: %w", err)
+	}
+
+	return models.RelayStats{
+		OutboxStats: outbox,
+		Delivered:   s.delivered.Load(),
+		Failed:      s.failed.Load(),
+	}, nil
+}
+
+// relay delivers one batch of events and returns its size.
+func (s *SearchRelay) relay(ctx context.Context) int {
+	events, err := s.outbox.Claim(ctx, relayBatch, relayLease)
+	if err != nil {
+		s.logger.Error("Claiming outbox events failed", zap.Error(err))
+		return 0
+	}
+
+	for _, e := range events {
+		if err := s.deliver(ctx, e); err != nil {
+			s.failed.Add(1)
+
+			if e.Attempts >= s.maxAttempts {
+				s.logger.Error("Delivering outbox event failed, parked as dead",
+					zap.Int64("id", e.Id),
+					zap.String("entity", e.Entity),
+					zap.Int("entity_id", e.EntityId),
+					zap.Int("attempts", e.Attempts),
+					zap.Error(err),
+				)
+
+				if err := s.outbox.Dead(ctx, e.Id, err.Error()); err != nil {
+					s.logger.Error("Parking outbox event failed", zap.Int64("id", e.Id), zap.Error(err))
+				}
+				continue
+			}
+
+			retryIn := backoff(e.Attempts)
+			s.logger.Warn("Delivering outbox event failed",
+				zap.Int64("id", e.Id),
+				zap.String("entity", e.Entity),
+				zap.Int("entity_id", e.EntityId),
+				zap.Int("attempts", e.Attempts),
+				zap.Duration("retry_in", retryIn),
+				zap.Error(err),
+			)
+
+			if err := s.outbox.Failed(ctx, e.Id, err.Error(), retryIn); err != nil {
+				s.logger.Error("Recording outbox failure failed", zap.Int64("id", e.Id), zap.Error(err))
+			}
+			continue
+		}
+
+		s.delivered.Add(1)
+
+		if err := s.outbox.Delivered(ctx, e.Id); err != nil {
+			// NOTE: The event is delivered again once its lease expires, which is harmless.
+			s.logger.Error("Acknowledging outbox event failed", zap.Int64("id", e.Id), zap.Error(err))
+		}
+	}
+
+	return len(events)
+}
+
+// deliver brings the search index up to date with the record of e.
+func (s *SearchRelay) deliver(ctx context.Context, e models.OutboxEvent) error {
+	ctx, span := tracer.Start(ctx, "SearchRelay.deliver")
+	defer span.End()
+
+	id := strconv.Itoa(e.EntityId)
+
+	switch e.Entity {
+	case models.EntityFilm:
+		return deliverRecord(ctx, e.Operation, id, s.films.Find, s.search.Index, s.search.Delete)
+	case models.EntityActor:
+		return deliverRecord(ctx, e.Operation, id, s.actors.Find, s.actorsSearch.Index, s.actorsSearch.Delete)
+	default:
+		return internal.NewErrorf(internal.ErrorCodeUnknown, "unknown entity %s", e.Entity)
+	}
+}
+
+// deliverRecord applies operation to the indexed record with the id. Index operations read the
+// current record, so events delivered late or out of order never make the index go back.
+func deliverRecord[T any](ctx context.Context, operation, id string,
+	find func(ctx context.Context, id string) (T, error),
+	index func(ctx context.Context, record T) error,
+	remove func(ctx context.Context, id string) error,
+) error {
+	if operation == models.OperationIndex {
+		record, err := find(ctx, id)
+		if err == nil {
+			if err := index(ctx, record); err != nil {
+				return fmt.Errorf("search index: %w", err)
+			}
+			return nil
+		}
+
+		var ierr *internal.Error
+		if !errors.As(err, &ierr) || ierr.Code() != internal.ErrorCodeNotFound {
+			return fmt.Errorf("repo find: %w", err)
+		}
+		// NOTE: Deleted since, remove it instead.
+	}
+
+	if err := remove(ctx, id); err != nil {
+		return fmt.Errorf("search delete: %w", err)
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the next delivery of an event that failed attempts times.
+func backoff(attempts int) time.Duration {
+	d := relayMinBackoff
+	for i := 1; i < attempts && d < relayMaxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, relayMaxBackoff)
+}
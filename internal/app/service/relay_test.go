@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// outboxStub records what the relay does with the claimed events.
+type outboxStub struct {
+	events []models.OutboxEvent
+
+	delivered []int64
+	failed    map[int64]time.Duration
+	dead      map[int64]string
+}
+
+func (o *outboxStub) Claim(_ context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	if lease != relayLease {
+		return nil, errors.New("unexpected lease")
+	}
+
+	n := min(limit, len(o.events))
+	claimed := o.events[:n]
+	o.events = o.events[n:]
+
+	return claimed, nil
+}
+
+func (o *outboxStub) Delivered(_ context.Context, id int64) error {
+	o.delivered = append(o.delivered, id)
+	return nil
+}
+
+func (o *outboxStub) Failed(_ context.Context, id int64, _ string, retryIn time.Duration) error {
+	o.failed[id] = retryIn
+	return nil
+}
+
+func (o *outboxStub) Dead(_ context.Context, id int64, cause string) error {
+	o.dead[id] = cause
+	return nil
+}
+
+func (o *outboxStub) Stats(context.Context) (models.OutboxStats, error) {
+	return models.OutboxStats{Pending: len(o.events)}, nil
+}
+
+func (o *outboxStub) Purge(context.Context, time.Duration) error {
+	return nil
+}
+
+// filmsStub finds the films of a map, the other methods aren't used by the relay.
+type filmsStub struct {
+	FilmRepository
+	films map[string]models.Film
+}
+
+func (f *filmsStub) Find(_ context.Context, id string) (models.Film, error) {
+	film, ok := f.films[id]
+	if !ok {
+		return models.Film{}, internal.NewErrorf(internal.ErrorCodeNotFound, "film not found")
+	}
+
+	return film, nil
+}
+
+// searchStub records the indexed and deleted films, failing for the ids in fail.
+type searchStub struct {
+	FilmSearchRepository
+	indexed []int
+	deleted []string
+	fail    map[int]bool
+}
+
+func (s *searchStub) Index(_ context.Context, film models.Film) error {
+	if s.fail[film.Id] {
+		return internal.NewErrorf(internal.ErrorCodeUnknown, "IndexRequest.Do 503")
+	}
+
+	s.indexed = append(s.indexed, film.Id)
+	return nil
+}
+
+func (s *searchStub) Delete(_ context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestSearchRelay_relay(t *testing.T) {
+	outbox := &outboxStub{
+		events: []models.OutboxEvent{
+			{Id: 1, Entity: models.EntityFilm, EntityId: 1, Operation: models.OperationIndex, Attempts: 1},
+			{Id: 2, Entity: models.EntityFilm, EntityId: 2, Operation: models.OperationDelete, Attempts: 1},
+			// NOTE: Deleted since the event was written.
+			{Id: 3, Entity: models.EntityFilm, EntityId: 3, Operation: models.OperationIndex, Attempts: 1},
+			{Id: 4, Entity: models.EntityFilm, EntityId: 4, Operation: models.OperationIndex, Attempts: 1},
+			{Id: 5, Entity: models.EntityFilm, EntityId: 4, Operation: models.OperationIndex, Attempts: 4},
+			{Id: 6, Entity: models.EntityFilm, EntityId: 4, Operation: models.OperationIndex, Attempts: 5},
+			{Id: 7, Entity: "unknown", EntityId: 1, Operation: models.OperationIndex, Attempts: 1},
+		},
+		failed: map[int64]time.Duration{},
+		dead:   map[int64]string{},
+	}
+	films := &filmsStub{films: map[string]models.Film{
+		"1": {Id: 1, Name: "Film 1"},
+		"4": {Id: 4, Name: "Film 4"},
+	}}
+	search := &searchStub{fail: map[int]bool{4: true}}
+
+	relay := NewSearchRelay(outbox, 5, films, search, nil, nil, zap.NewNop())
+
+	require.Equal(t, 7, relay.relay(context.Background()))
+
+	assert.Equal(t, []int64{1, 2, 3}, outbox.delivered)
+	assert.Equal(t, []int{1}, search.indexed)
+	assert.Equal(t, []string{"2", "3"}, search.deleted)
+
+	assert.Equal(t, map[int64]time.Duration{
+		4: relayMinBackoff,
+		5: 8 * relayMinBackoff,
+		7: relayMinBackoff,
+	}, outbox.failed)
+	assert.Equal(t, map[int64]string{
+		6: "search index: IndexRequest.Do 503",
+	}, outbox.dead)
+
+	stats, err := relay.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), stats.Delivered)
+	assert.Equal(t, uint64(4), stats.Failed)
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: relayMaxBackoff},
+		{attempts: 50, want: relayMaxBackoff},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, backoff(tc.attempts), "attempts %d", tc.attempts)
+	}
+}
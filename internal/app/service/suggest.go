@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// Suggester defines the search index suggesting records while their name is being typed.
+type Suggester interface {
+	Suggest(ctx context.Context, q string, limit int) ([]models.Suggestion, error)
+}
+
+// SuggestService defines the application service in charge of type-ahead suggestions.
+type SuggestService struct {
+	films  Suggester
+	actors Suggester
+}
+
+// NewSuggestService ...
+func NewSuggestService(films, actors Suggester) *SuggestService {
+	return &SuggestService{
+		films:  films,
+		actors: actors,
+	}
+}
+
+// Suggest gets the Films and the Actors whose name starts like p.Query, the films and the actors
+// are looked up concurrently.
+func (s *SuggestService) Suggest(ctx context.Context, p m.Suggest) (models.Suggestions, error) {
+	ctx, span := tracer.Start(ctx, "SuggestService.Suggest")
+	defer span.End()
+
+	if err := p.Validate(); err != nil {
+		return models.Suggestions{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "validate suggest")
+	}
+
+	var (
+		res                 models.Suggestions
+		filmsErr, actorsErr error
+		wg                  sync.WaitGroup
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		res.Films, filmsErr = s.films.Suggest(ctx, p.Query, p.Limit)
+	}()
+	go func() {
+		defer wg.Done()
+		res.Actors, actorsErr = s.actors.Suggest(ctx, p.Query, p.Limit)
+	}()
+	wg.Wait()
+
+	if filmsErr != nil {
+		return models.Suggestions{}, fmt.Errorf("suggest films: %w", filmsErr)
+	}
+	if actorsErr != nil {
+		return models.Suggestions{}, fmt.Errorf("suggest actors: %w", actorsErr)
+	}
+
+	return res, nil
+}
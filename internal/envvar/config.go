@@ -0,0 +1,283 @@
+package envvar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the commands, see LoadConfig. Every setting has a key in the
+// YAML file and an environment variable, `env`, which takes precedence; `default` is used when
+// neither is set and `secret` settings are redacted when printed.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Search   SearchConfig   `yaml:"search"`
+	Cursor   CursorConfig   `yaml:"cursor"`
+	Swagger  SwaggerConfig  `yaml:"swagger"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	BindAddr string `yaml:"bind_addr" env:"BIND_ADDR" default:":8080" validate:"required"`
+	// ReadTimeout covers reading the whole request, body included, WriteTimeout from the end of
+	// the request headers to the end of the response: bulk requests and slow clients need both.
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s" validate:"gt=0"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s" validate:"gt=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s" validate:"gt=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s" validate:"gt=0"`
+	// RequestTimeout is the deadline of the work done for a request, SQL statements and search
+	// calls included; keep it below WriteTimeout so that timed out requests still get a response.
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" default:"10s" validate:"gt=0"`
+	// ShutdownDelay is how long requests are still accepted once a shutdown starts while the
+	// readiness check fails, so that load balancers stop routing new requests here first.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" default:"5s" validate:"gte=0"`
+	// ShutdownTimeout is how long in-flight requests have to complete once they stop being
+	// accepted.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"5s" validate:"gt=0"`
+	// MaxHeaderBytes and MaxBodyBytes limit the size of the request headers and bodies, larger
+	// bodies are rejected with 413 Request Entity Too Large.
+	MaxHeaderBytes int `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576" validate:"gt=0"`
+	MaxBodyBytes   int `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" default:"1048576" validate:"gt=0"`
+}
+
+// DatabaseConfig configures the Postgres database.
+type DatabaseConfig struct {
+	// URL is a connection string, either a URL or `key=value` pairs.
+	URL string `yaml:"url" env:"DB_URL" validate:"required" secret:"true"`
+}
+
+// SearchConfig configures Elasticsearch.
+type SearchConfig struct {
+	URL string `yaml:"url" env:"ELASTICSEARCH_URL" default:"http://localhost:9200" validate:"required,url"`
+	// FilmsIndex and ActorsIndex are the names of the aliases of the indices.
+	FilmsIndex  string `yaml:"films_index" env:"ES_INDEX" default:"films" validate:"required"`
+	ActorsIndex string `yaml:"actors_index" env:"ES_ACTORS_INDEX" default:"actors" validate:"required"`
+	// OutboxMaxAttempts is how many times the delivery of a change to the search index is tried
+	// before the change is parked as dead, about an hour with the default backoff.
+	OutboxMaxAttempts int `yaml:"outbox_max_attempts" env:"SEARCH_OUTBOX_MAX_ATTEMPTS" default:"20" validate:"gt=0"`
+}
+
+// CursorConfig configures paging cursors.
+type CursorConfig struct {
+	// Secret signs the cursors, a random one is used when empty so cursors don't survive
+	// restarts nor work across replicas. Set it through CURSOR_SECRET_SECURE, anyone knowing it
+	// can forge cursors.
+	Secret string `yaml:"secret" env:"CURSOR_SECRET" secret:"true" validate:"omitempty,min=32"`
+}
+
+// SwaggerConfig configures the API documentation.
+type SwaggerConfig struct {
+	URL string `yaml:"url" env:"SWAG_URL" default:"./docs/doc.json" validate:"required"`
+}
+
+// TracingConfig configures OpenTelemetry tracing.
+type TracingConfig struct {
+	// Exporter is where spans are sent: none, otlp to the OTLP/HTTP collector at OTLPEndpoint,
+	// stdout, or file, which appends them as JSON to File.
+	Exporter     string `yaml:"exporter" env:"TRACING_EXPORTER" default:"none" validate:"oneof=none otlp stdout file"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" default:"http://localhost:4318" validate:"required,url"`
+	File         string `yaml:"file" env:"TRACING_FILE" default:"traces.json" validate:"required"`
+	ServiceName  string `yaml:"service_name" env:"TRACING_SERVICE_NAME" default:"filmoteka" validate:"required"`
+	// SampleRatio is the share of the traces started here which are recorded, traces started by
+	// callers follow their sampling decision.
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" validate:"gte=0,lte=1"`
+}
+
+// redacted replaces the secrets in printed configurations.
+const redacted = "REDACTED"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadConfig returns the configuration: the defaults, overridden by the YAML file when filename
+// is not empty, overridden by the environment variables read with provider. The configuration is
+// validated, the error names every invalid setting.
+func LoadConfig(provider Provider, filename string) (*Config, error) {
+	var conf Config
+
+	if err := walk(reflect.ValueOf(&conf).Elem(), func(field reflect.Value, sf reflect.StructField) error {
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			return setValue(field, def)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("defaults: %w", err)
+	}
+
+	if filename != "" {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		dec.KnownFields(true)
+		if err := dec.Decode(&conf); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parsing config file %s: %w", filename, err)
+		}
+	}
+
+	if err := walk(reflect.ValueOf(&conf).Elem(), func(field reflect.Value, sf reflect.StructField) error {
+		key, ok := sf.Tag.Lookup("env")
+		if !ok {
+			return nil
+		}
+
+		value, err := provider.Get(key)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return nil
+		}
+
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("environment: %w", err)
+	}
+
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// validate returns an error naming every invalid setting with its key and environment variable.
+func (c *Config) validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		name := strings.SplitN(sf.Tag.Get("yaml"), ",", 2)[0]
+		if env := sf.Tag.Get("env"); env != "" {
+			name += " (" + env + ")"
+		}
+		return name
+	})
+
+	err := validate.Struct(c)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return fmt.Errorf("validating config: %w", err)
+	}
+
+	msgs := make([]string, len(verrs))
+	for i, verr := range verrs {
+		_, path, _ := strings.Cut(verr.Namespace(), ".")
+
+		switch verr.Tag() {
+		case "required":
+			msgs[i] = path + " is required"
+		case "gt":
+			msgs[i] = path + " must be positive"
+		case "gte":
+			msgs[i] = path + " must be at least " + verr.Param()
+		case "lte":
+			msgs[i] = path + " must be at most " + verr.Param()
+		case "min":
+			msgs[i] = path + " must be at least " + verr.Param() + " characters long"
+		case "oneof":
+			msgs[i] = path + " must be one of " + verr.Param()
+		default:
+			msgs[i] = path + " must be a valid " + verr.Tag()
+		}
+	}
+
+	return fmt.Errorf("invalid config: %s", strings.Join(msgs, ", "))
+}
+
+// Print writes the configuration as YAML with the secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	conf := *c
+
+	if err := walk(reflect.ValueOf(&conf).Elem(), func(field reflect.Value, sf reflect.StructField) error {
+		if sf.Tag.Get("secret") == "true" && !field.IsZero() {
+			field.SetString(redacted)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(conf); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// walk calls fn for every setting of the struct v, recursing into the sections.
+func walk(v reflect.Value, fn func(field reflect.Value, sf reflect.StructField) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			if err := walk(v.Field(i), fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(v.Field(i), sf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setValue sets the setting field from its text representation.
+func setValue(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration, e.g. 5s: %w", err)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be a whole number: %w", err)
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number: %w", err)
+		}
+		field.SetFloat(f)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false: %w", err)
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package envvar_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"filmoteka/internal/envvar"
+)
+
+// clearEnv unsets, for the test, the environment variables of every setting so that the
+// environment running the tests doesn't leak into the configuration.
+func clearEnv(t *testing.T, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			clearEnv(t, sf.Type)
+			continue
+		}
+
+		if key, ok := sf.Tag.Lookup("env"); ok {
+			t.Setenv(key, "")
+			t.Setenv(key+"_SECURE", "")
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	mounted := filepath.Join(dir, "db_url")
+	require.NoError(t, os.WriteFile(mounted, []byte("host=secret\n"), 0o600))
+
+	testCases := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, c *envvar.Config)
+		err   string
+	}{
+		{
+			name: "defaults",
+			file: "database:\n  url: host=file\n",
+			check: func(t *testing.T, c *envvar.Config) {
+				assert.Equal(t, ":8080", c.Server.BindAddr)
+				assert.Equal(t, 15*time.Second, c.Server.ReadTimeout)
+				assert.Equal(t, 5*time.Second, c.Server.ShutdownDelay)
+				assert.Equal(t, 1048576, c.Server.MaxBodyBytes)
+				assert.Equal(t, "films", c.Search.FilmsIndex)
+				assert.Equal(t, 20, c.Search.OutboxMaxAttempts)
+				assert.Equal(t, "none", c.Tracing.Exporter)
+				assert.Equal(t, float64(1), c.Tracing.SampleRatio)
+				assert.Empty(t, c.Cursor.Secret)
+			},
+		},
+		{
+			name: "file only",
+			file: "server:\n  bind_addr: :9090\n  read_timeout: 20s\n" +
+				"database:\n  url: host=file\n" +
+				"search:\n  films_index: movies\n  outbox_max_attempts: 5\n" +
+				"tracing:\n  sample_ratio: 0.5\n",
+			check: func(t *testing.T, c *envvar.Config) {
+				assert.Equal(t, ":9090", c.Server.BindAddr)
+				assert.Equal(t, 20*time.Second, c.Server.ReadTimeout)
+				assert.Equal(t, "host=file", c.Database.URL)
+				assert.Equal(t, "movies", c.Search.FilmsIndex)
+				assert.Equal(t, 5, c.Search.OutboxMaxAttempts)
+				assert.Equal(t, 0.5, c.Tracing.SampleRatio)
+				// NOTE: Settings missing from the file keep their defaults.
+				assert.Equal(t, "actors", c.Search.ActorsIndex)
+			},
+		},
+		{
+			name: "env overrides file",
+			file: "server:\n  bind_addr: :9090\n  read_timeout: 20s\n" +
+				"database:\n  url: host=file\n",
+			env: map[string]string{
+				"BIND_ADDR":           ":7070",
+				"SERVER_READ_TIMEOUT": "25s",
+				"ES_INDEX":            "movies",
+			},
+			check: func(t *testing.T, c *envvar.Config) {
+				assert.Equal(t, ":7070", c.Server.BindAddr)
+				assert.Equal(t, 25*time.Second, c.Server.ReadTimeout)
+				assert.Equal(t, "host=file", c.Database.URL)
+				assert.Equal(t, "movies", c.Search.FilmsIndex)
+			},
+		},
+		{
+			name: "secure",
+			file: "database:\n  url: host=file\n",
+			env: map[string]string{
+				"DB_URL":        "host=env",
+				"DB_URL_SECURE": "file:" + mounted,
+			},
+			check: func(t *testing.T, c *envvar.Config) {
+				assert.Equal(t, "host=secret", c.Database.URL)
+			},
+		},
+		{
+			name: "unknown key",
+			file: "server:\n  bind_address: :9090\n",
+			env:  map[string]string{"DB_URL": "host=env"},
+			err:  "field bind_address not found",
+		},
+		{
+			name: "missing secret",
+			env:  map[string]string{"DB_URL_SECURE": filepath.Join(dir, "unknown")},
+			err:  "environment: DB_URL_SECURE: reading secret file",
+		},
+		{
+			name: "invalid env",
+			env:  map[string]string{"DB_URL": "host=env", "SERVER_READ_TIMEOUT": "soon"},
+			err:  "environment: SERVER_READ_TIMEOUT: must be a duration",
+		},
+		{
+			name: "weak cursor secret",
+			file: "database:\n  url: host=file\n",
+			env:  map[string]string{"CURSOR_SECRET": "change-me"},
+			err:  "invalid config: cursor.secret (CURSOR_SECRET) must be at least 32 characters long",
+		},
+		{
+			name: "invalid values",
+			file: "tracing:\n  exporter: jaeger\n",
+			err: "invalid config: database.url (DB_URL) is required, " +
+				"tracing.exporter (TRACING_EXPORTER) must be one of none otlp stdout file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t, reflect.TypeOf(envvar.Config{}))
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			var filename string
+			if tc.file != "" {
+				filename = filepath.Join(t.TempDir(), "config.yaml")
+				require.NoError(t, os.WriteFile(filename, []byte(tc.file), 0o600))
+			}
+
+			conf, err := envvar.LoadConfig(envvar.New(envvar.NewSecrets(nil)), filename)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			tc.check(t, conf)
+		})
+	}
+}
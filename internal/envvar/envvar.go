@@ -15,10 +15,20 @@ type Provider interface {
 }
 
 // Configuration ...
-type Configuration struct{}
+type Configuration struct {
+	provider Provider
+}
 
-// Load read the env filename and load it into ENV for this process.
+// Load read the env filename and load it into ENV for this process, variables already set are
+// kept. Without filename `.env` is loaded, if it exists.
 func Load(filename string) error {
+	if filename == "" {
+		if _, err := os.Stat(".env"); err != nil {
+			return nil
+		}
+		filename = ".env"
+	}
+
 	if err := godotenv.Load(filename); err != nil {
 		return fmt.Errorf("loading env var file: %w", err)
 	}
@@ -27,14 +37,30 @@ func Load(filename string) error {
 }
 
 // New ...
-func New() *Configuration {
-	return &Configuration{}
+func New(provider Provider) *Configuration {
+	return &Configuration{
+		provider: provider,
+	}
 }
 
 // Get returns the value from environment variable `<key>`. When an environment variable `<key>_SECURE` exists
-// the provider is used for getting the value.
+// the provider is used for getting the value, its value is the reference to the secret, see Secrets.
 func (c *Configuration) Get(key string) (string, error) {
 	res := os.Getenv(key)
 
-	return res, nil
+	ref := os.Getenv(key + "_SECURE")
+	if ref == "" {
+		return res, nil
+	}
+
+	if c.provider == nil {
+		return "", fmt.Errorf("%s_SECURE: no secret provider", key)
+	}
+
+	value, err := c.provider.Get(ref)
+	if err != nil {
+		return "", fmt.Errorf("%s_SECURE: %w", key, err)
+	}
+
+	return value, nil
 }
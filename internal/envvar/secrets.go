@@ -0,0 +1,226 @@
+package envvar
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// SecretsFileEnv and SecretsKeyEnv are the environment variables holding the path of the
+	// encrypted local secrets file and its base64 encoded 32 bytes key.
+	SecretsFileEnv = "SECRETS_FILE"
+	SecretsKeyEnv  = "SECRETS_KEY"
+
+	fileScheme  = "file:"
+	localScheme = "local:"
+)
+
+// Secrets is the Provider of the values of the `<key>_SECURE` environment variables, which
+// hold references to the secrets:
+//
+//   - `file:<path>`, or just `<path>`, is a file holding the secret, as mounted by Docker and
+//     Kubernetes secrets, e.g. `/run/secrets/db_url`. Surrounding whitespace is trimmed.
+//   - `local:<name>` is the secret with the name in the encrypted local secrets file.
+type Secrets struct {
+	local *LocalSecrets
+}
+
+// NewSecrets returns the secrets provider, local is the encrypted local secrets file, nil when
+// there is none.
+func NewSecrets(local *LocalSecrets) *Secrets {
+	return &Secrets{
+		local: local,
+	}
+}
+
+// NewSecretsFromEnv returns the secrets provider, with the encrypted local secrets file named by
+// SecretsFileEnv when set.
+func NewSecretsFromEnv() (*Secrets, error) {
+	filename := os.Getenv(SecretsFileEnv)
+	if filename == "" {
+		return NewSecrets(nil), nil
+	}
+
+	key, err := DecodeSecretsKey(os.Getenv(SecretsKeyEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", SecretsKeyEnv, err)
+	}
+
+	local, err := OpenLocalSecrets(filename, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSecrets(local), nil
+}
+
+// Get returns the secret the reference points to.
+func (s *Secrets) Get(ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, localScheme); ok {
+		if s.local == nil {
+			return "", fmt.Errorf("secret %s: no local secrets file, set %s", name, SecretsFileEnv)
+		}
+		return s.local.Get(name)
+	}
+
+	path := strings.TrimPrefix(ref, fileScheme)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading secret file: %w", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// LocalSecrets is a file of named secrets encrypted with AES-256-GCM, for keeping secrets out of
+// the env files on development machines. The file holds the nonce followed by the sealed JSON
+// object of the secrets.
+type LocalSecrets struct {
+	filename string
+	key      []byte
+	secrets  map[string]string
+}
+
+// OpenLocalSecrets decrypts the local secrets file, a missing file has no secrets.
+func OpenLocalSecrets(filename string, key []byte) (*LocalSecrets, error) {
+	s := &LocalSecrets{
+		filename: filename,
+		key:      key,
+		secrets:  map[string]string{},
+	}
+
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets file: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) < aead.NonceSize() {
+		return nil, fmt.Errorf("secrets file %s is truncated", filename)
+	}
+
+	nonce, sealed := content[:aead.NonceSize()], content[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting secrets file %s, wrong key? %w", filename, err)
+	}
+
+	if err := json.Unmarshal(plain, &s.secrets); err != nil {
+		return nil, fmt.Errorf("parsing secrets file %s: %w", filename, err)
+	}
+
+	return s, nil
+}
+
+// Get returns the secret with the name.
+func (s *LocalSecrets) Get(name string) (string, error) {
+	value, ok := s.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s is not in %s", name, s.filename)
+	}
+
+	return value, nil
+}
+
+// Names returns the names of the secrets.
+func (s *LocalSecrets) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Set adds or replaces a secret, see Save.
+func (s *LocalSecrets) Set(name, value string) {
+	s.secrets[name] = value
+}
+
+// Delete removes a secret, see Save.
+func (s *LocalSecrets) Delete(name string) {
+	delete(s.secrets, name)
+}
+
+// Save encrypts the secrets into the file, readable by its owner only.
+func (s *LocalSecrets) Save() error {
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("encoding secrets: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+
+	if err := os.WriteFile(s.filename, aead.Seal(nonce, nonce, plain, nil), 0o600); err != nil {
+		return fmt.Errorf("writing secrets file: %w", err)
+	}
+
+	return nil
+}
+
+// NewSecretsKey returns a new random key for a local secrets file, base64 encoded.
+func NewSecretsKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// DecodeSecretsKey decodes a key returned by NewSecretsKey.
+func DecodeSecretsKey(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return nil, errors.New("the key is not set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the key must be base64 encoded: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("the key must be 32 bytes long, not %d", len(key))
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cipher.NewGCM: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,113 @@
+package envvar_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"filmoteka/internal/envvar"
+)
+
+func TestConfiguration_Get(t *testing.T) {
+	dir := t.TempDir()
+
+	encoded, err := envvar.NewSecretsKey()
+	assert.NoError(t, err)
+	key, err := envvar.DecodeSecretsKey(encoded)
+	assert.NoError(t, err)
+
+	local, err := envvar.OpenLocalSecrets(filepath.Join(dir, "secrets.enc"), key)
+	assert.NoError(t, err)
+	local.Set("db_url", "host=db password=secret")
+	assert.NoError(t, local.Save())
+
+	mounted := filepath.Join(dir, "cursor_secret")
+	assert.NoError(t, os.WriteFile(mounted, []byte("mounted\n"), 0o600))
+
+	reopened, err := envvar.OpenLocalSecrets(filepath.Join(dir, "secrets.enc"), key)
+	assert.NoError(t, err)
+
+	conf := envvar.New(envvar.NewSecrets(reopened))
+
+	testCases := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		isValid bool
+	}{
+		{
+			name:    "plain",
+			env:     map[string]string{"TEST_KEY": "plain"},
+			want:    "plain",
+			isValid: true,
+		},
+		{
+			name:    "file",
+			env:     map[string]string{"TEST_KEY": "plain", "TEST_KEY_SECURE": "file:" + mounted},
+			want:    "mounted",
+			isValid: true,
+		},
+		{
+			name:    "path",
+			env:     map[string]string{"TEST_KEY_SECURE": mounted},
+			want:    "mounted",
+			isValid: true,
+		},
+		{
+			name:    "local",
+			env:     map[string]string{"TEST_KEY_SECURE": "local:db_url"},
+			want:    "host=db password=secret",
+			isValid: true,
+		},
+		{
+			name:    "missing local",
+			env:     map[string]string{"TEST_KEY_SECURE": "local:unknown"},
+			isValid: false,
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"TEST_KEY_SECURE": filepath.Join(dir, "unknown")},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TEST_KEY", "")
+			t.Setenv("TEST_KEY_SECURE", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := conf.Get("TEST_KEY")
+			if tc.isValid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestOpenLocalSecrets_WrongKey(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "secrets.enc")
+
+	for i, name := range []string{"right", "wrong"} {
+		encoded, err := envvar.NewSecretsKey()
+		assert.NoError(t, err)
+		key, err := envvar.DecodeSecretsKey(encoded)
+		assert.NoError(t, err)
+
+		local, err := envvar.OpenLocalSecrets(filename, key)
+		if i == 0 {
+			assert.NoError(t, err, name)
+			local.Set("db_url", "secret")
+			assert.NoError(t, local.Save())
+		} else {
+			assert.Error(t, err, name)
+		}
+	}
+}
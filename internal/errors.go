@@ -20,6 +20,9 @@ const (
 	ErrorCodeNotFound
 	ErrorCodeInvalidArgument
 	ErrorCodeUniqueConstraints
+	ErrorCodePreconditionFailed
+	ErrorCodeUnavailable
+	ErrorCodeRequestTooLarge
 )
 
 // WrapErrorf returns a wrapped error.
@@ -0,0 +1,208 @@
+// Package metrics exposes the Prometheus metrics of the service: HTTP requests, the database
+// connection pool, the calls to the search backend, the outbox delivery and the circuit breaker.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"filmoteka/internal/app/models"
+)
+
+const namespace = "filmoteka"
+
+// statsTimeout bounds the database query of the outbox metrics, scrapes don't carry a context.
+const statsTimeout = 5 * time.Second
+
+// RelayStats reports the outbox delivery, see service.SearchRelay.
+type RelayStats interface {
+	Stats(ctx context.Context) (models.RelayStats, error)
+}
+
+// BreakerStats reports the state of a circuit breaker, see service.ResilientFilmSearch.
+type BreakerStats interface {
+	Stats() models.BreakerStats
+}
+
+// Metrics holds the collectors of the service in their own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	searchDuration  *prometheus.HistogramVec
+	searchErrors    *prometheus.CounterVec
+}
+
+// New registers the collectors of the HTTP requests, the database connection pool and the calls
+// to the search backend, see WatchSearch for the outbox delivery and the circuit breaker.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests by route template and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		searchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "search_call_duration_seconds",
+			Help:      "Duration of the calls to the search backend by operation, retries included.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+		searchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "search_call_errors_total",
+			Help:      "Failed calls to the search backend by operation, retries included.",
+		}, []string{"backend", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "filmoteka"),
+		m.requests,
+		m.requestDuration,
+		m.searchDuration,
+		m.searchErrors,
+	)
+
+	return m
+}
+
+// WatchSearch registers the collectors of the outbox delivery and the state of the circuit
+// breaker, read when scraped.
+func (m *Metrics) WatchSearch(relay RelayStats, breaker BreakerStats) {
+	m.registry.MustRegister(&searchCollector{relay: relay, breaker: breaker})
+}
+
+// Handler returns the handler of `/metrics`.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Register registers the handler of `/metrics`.
+func (m *Metrics) Register(r *mux.Router) {
+	r.Handle("/metrics", m.Handler()).Methods(http.MethodGet)
+}
+
+// Middleware counts and times the requests, labelled by route template, e.g. `/films/{id}`, so
+// that the cardinality doesn't grow with the ids.
+func (m *Metrics) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		h.ServeHTTP(rec, r)
+
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+	})
+}
+
+// ObserveSearch records a call to the search backend, see service.SearchObserver.
+func (m *Metrics) ObserveSearch(backend, operation string, took time.Duration, err error) {
+	m.searchDuration.WithLabelValues(backend, operation).Observe(took.Seconds())
+	if err != nil {
+		m.searchErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+var (
+	outboxPending = prometheus.NewDesc(namespace+"_search_outbox_pending",
+		"Changes waiting to be delivered to the search index.", nil, nil)
+	outboxRetrying = prometheus.NewDesc(namespace+"_search_outbox_retrying",
+		"Pending changes that failed to be delivered at least once.", nil, nil)
+	outboxLag = prometheus.NewDesc(namespace+"_search_outbox_lag_seconds",
+		"Age of the oldest change waiting to be delivered to the search index.", nil, nil)
+	outboxDead = prometheus.NewDesc(namespace+"_search_outbox_dead",
+		"Changes parked after failing every delivery attempt.", nil, nil)
+	outboxDelivered = prometheus.NewDesc(namespace+"_search_outbox_delivered_total",
+		"Changes delivered to the search index.", nil, nil)
+	outboxFailed = prometheus.NewDesc(namespace+"_search_outbox_failed_total",
+		"Failed deliveries of changes to the search index.", nil, nil)
+
+	breakerState = prometheus.NewDesc(namespace+"_circuit_breaker_state",
+		"State of the circuit breaker: 0 closed, 1 half open, 2 open.", []string{"name"}, nil)
+	breakerOpens = prometheus.NewDesc(namespace+"_circuit_breaker_opens_total",
+		"Times the circuit breaker opened.", []string{"name"}, nil)
+	breakerRejected = prometheus.NewDesc(namespace+"_circuit_breaker_rejected_total",
+		"Calls failed fast by the circuit breaker.", []string{"name"}, nil)
+	breakerRetries = prometheus.NewDesc(namespace+"_circuit_breaker_retries_total",
+		"Calls retried.", []string{"name"}, nil)
+)
+
+// breakerStates are the values of the circuit breaker states in metrics.
+var breakerStates = map[string]float64{
+	models.BreakerClosed:   0,
+	models.BreakerHalfOpen: 1,
+	models.BreakerOpen:     2,
+}
+
+// searchCollector collects the outbox delivery and the circuit breaker state when scraped.
+type searchCollector struct {
+	relay   RelayStats
+	breaker BreakerStats
+}
+
+func (c *searchCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		outboxPending, outboxRetrying, outboxLag, outboxDead, outboxDelivered, outboxFailed,
+		breakerState, breakerOpens, breakerRejected, breakerRetries,
+	} {
+		ch <- d
+	}
+}
+
+func (c *searchCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	// NOTE: The outbox metrics are left out of the scrape while the database is unavailable.
+	if stats, err := c.relay.Stats(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(outboxPending, prometheus.GaugeValue, float64(stats.Pending))
+		ch <- prometheus.MustNewConstMetric(outboxRetrying, prometheus.GaugeValue, float64(stats.Retrying))
+		ch <- prometheus.MustNewConstMetric(outboxLag, prometheus.GaugeValue, stats.LagSeconds)
+		ch <- prometheus.MustNewConstMetric(outboxDead, prometheus.GaugeValue, float64(stats.Dead))
+		ch <- prometheus.MustNewConstMetric(outboxDelivered, prometheus.CounterValue, float64(stats.Delivered))
+		ch <- prometheus.MustNewConstMetric(outboxFailed, prometheus.CounterValue, float64(stats.Failed))
+	}
+
+	stats := c.breaker.Stats()
+	ch <- prometheus.MustNewConstMetric(breakerState, prometheus.GaugeValue, breakerStates[stats.State], stats.Name)
+	ch <- prometheus.MustNewConstMetric(breakerOpens, prometheus.CounterValue, float64(stats.Opens), stats.Name)
+	ch <- prometheus.MustNewConstMetric(breakerRejected, prometheus.CounterValue, float64(stats.Rejected), stats.Name)
+	ch <- prometheus.MustNewConstMetric(breakerRetries, prometheus.CounterValue, float64(stats.Retries), stats.Name)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"filmoteka/internal/app/models"
+)
+
+type relayStub struct{}
+
+func (relayStub) Stats(context.Context) (models.RelayStats, error) {
+	return models.RelayStats{
+		OutboxStats: models.OutboxStats{Pending: 3, Retrying: 1, LagSeconds: 12.5, Dead: 2},
+		Delivered:   7,
+	}, nil
+}
+
+type breakerStub struct{}
+
+func (breakerStub) Stats() models.BreakerStats {
+	return models.BreakerStats{Name: "elasticsearch", State: models.BreakerOpen, Opens: 2}
+}
+
+func scrape(t *testing.T, r *mux.Router) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	// NOTE: Opening doesn't connect, the pool stats are still collected.
+	db, err := sql.Open("postgres", "postgres://localhost/filmoteka")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m := New(db)
+	m.WatchSearch(relayStub{}, breakerStub{})
+	m.ObserveSearch("elasticsearch", "search", 30*time.Millisecond, nil)
+	m.ObserveSearch("elasticsearch", "index", time.Second, errors.New("timeout"))
+
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/films/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	m.Register(r)
+
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/films/"+id, nil))
+	}
+
+	body := scrape(t, r)
+
+	for _, want := range []string{
+		`filmoteka_http_requests_total{method="GET",route="/films/{id}",status="404"} 2`,
+		`filmoteka_http_request_duration_seconds_count{method="GET",route="/films/{id}"} 2`,
+		`go_sql_max_open_connections{db_name="filmoteka"} 0`,
+		`filmoteka_search_call_duration_seconds_count{backend="elasticsearch",operation="search"} 1`,
+		`filmoteka_search_call_errors_total{backend="elasticsearch",operation="index"} 1`,
+		`filmoteka_search_outbox_pending 3`,
+		`filmoteka_search_outbox_lag_seconds 12.5`,
+		`filmoteka_search_outbox_dead 2`,
+		`filmoteka_search_outbox_delivered_total 7`,
+		`filmoteka_circuit_breaker_state{name="elasticsearch"} 2`,
+		`filmoteka_circuit_breaker_opens_total{name="elasticsearch"} 2`,
+	} {
+		assert.Contains(t, body, want)
+	}
+
+	assert.NotContains(t, body, `filmoteka_search_call_errors_total{backend="elasticsearch",operation="search"}`)
+}
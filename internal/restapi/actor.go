@@ -1,9 +1,11 @@
 package restapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -16,30 +18,36 @@ import (
 
 // ActorService
 type ActorService interface {
-	Create(a m.CreateActor) (models.Actor, error)
-	Delete(id string) error
-	Search() ([]models.Actor, error)
-	Find(id string) (models.Actor, error)
-	Update(id string, a m.UpdateActor) error
+	Create(ctx context.Context, a m.CreateActor) (models.Actor, error)
+	Delete(ctx context.Context, id string, version int) error
+	Search(ctx context.Context, p m.ListActors) ([]models.Actor, int, error)
+	FullTextSearch(ctx context.Context, p m.SearchActors) (models.ActorSearchResult, error)
+	Find(ctx context.Context, id string) (models.Actor, error)
+	Update(ctx context.Context, id string, a m.UpdateActor, version int) (int, error)
+	Patch(ctx context.Context, id string, p m.Patch, version int) (models.Actor, error)
 }
 
 // ActorHandler
 type ActorHandler struct {
-	svc ActorService
+	svc     ActorService
+	cursors *Cursors
 }
 
 // NewActorHandler ...
-func NewActorHandler(svc ActorService) *ActorHandler {
+func NewActorHandler(svc ActorService, cursors *Cursors) *ActorHandler {
 	return &ActorHandler{
-		svc: svc,
+		svc:     svc,
+		cursors: cursors,
 	}
 }
 
 func (h *ActorHandler) Register(r *mux.Router) {
 	r.HandleFunc("/actors", h.create).Methods(http.MethodPost)
 	r.HandleFunc("/actors", h.search).Methods(http.MethodGet)
+	r.HandleFunc("/actors/search", h.fullTextSearch).Methods(http.MethodGet)
 	r.HandleFunc("/actors/{id}", h.find).Methods(http.MethodGet)
 	r.HandleFunc("/actors/{id}", h.update).Methods(http.MethodPut)
+	r.HandleFunc("/actors/{id}", h.patch).Methods(http.MethodPatch)
 	r.HandleFunc("/actors/{id}", h.delete).Methods(http.MethodDelete)
 }
 
@@ -56,7 +64,7 @@ func (h *ActorHandler) Register(r *mux.Router) {
 func (h *ActorHandler) create(w http.ResponseWriter, r *http.Request) {
 	var req m.CreateActor
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		e := internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "json decoder")
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
 		msg := fmt.Errorf("invalid request %w", e)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -64,7 +72,7 @@ func (h *ActorHandler) create(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	actor, err := h.svc.Create(req)
+	actor, err := h.svc.Create(r.Context(), req)
 	if err != nil {
 		// fmt.Println(err)
 		msg := fmt.Errorf("create failed: %w", err)
@@ -81,16 +89,25 @@ func (h *ActorHandler) create(w http.ResponseWriter, r *http.Request) {
 //
 // @Description	delete one actors by id
 // @Param		id		path		int		true	"Actor ID"
+// @Param		If-Match	header	string	false	"ETag of the version being changed, without it the change is unconditional"
 // @Produce		json
 // @Success		200		string		null				"ok"
 // @Failure		400		{object}	internal.Error	"Bad request"
 // @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		412		{object}	internal.Error	"Precondition failed"
 // @Failure		500		{object}	internal.Error	"Internal error"
 // @Router		/actors/{id} [delete]
 func (h *ActorHandler) delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	if err := h.svc.Delete(id); err != nil {
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id, version); err != nil {
 		msg := fmt.Errorf("delete failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -99,31 +116,149 @@ func (h *ActorHandler) delete(w http.ResponseWriter, r *http.Request) {
 	renderResponse(w, struct{}{}, http.StatusOK)
 }
 
-// ReadTasksResponse defines the response returned back after searching one task.
+// ReadActorResponse defines the response returned back after searching one actor.
 type ReadActorResponse struct {
 	Film models.Actor `json:"actor"`
 }
 
+// ListActorsResponse defines the response returned back after listing actors.
+type ListActorsResponse struct {
+	Items []models.Actor `json:"items"`
+	Page
+}
+
 //	@Tags Actors
 //
-// @Description	get all actors
+// @Description	get a page of actors
 // @Accept		json
 // @Produce		json
-// @Success		200		{object}	[]models.Actor			"ok"
+// @Param		limit		query		int		false	"Page size, 1 to 100"	default(20)
+// @Param		offset		query		int		false	"Number of actors to skip"	default(0)
+// @Param		cursor		query		string	false	"next_cursor of the previous page, replaces offset"
+// @Param		sort		query		string	false	"Comma separated fields, `-` for descending, e.g. -birth_date,name"
+// @Param		gender		query		string	false	"M or F"
+// @Param		born_from	query		string	false	"Earliest birth date, 2006-01-02 format"
+// @Param		born_to		query		string	false	"Latest birth date, 2006-01-02 format"
+// @Param		If-None-Match	header	string	false	"ETag of the cached page"
+// @Success		200		{object}	ListActorsResponse			"ok"
 // @Failure		400		{object}	internal.Error	"Bad request"
-// @Failure		404		{object}	internal.Error	"Resource not found"
 // @Failure		500		{object}	internal.Error	"Internal error"
 // @Router		/actors [get]
 func (h *ActorHandler) search(w http.ResponseWriter, r *http.Request) {
-	actors, err := h.svc.Search()
+	p, err := h.cursors.parseListActors(r.URL.Query())
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	actors, total, err := h.svc.Search(r.Context(), p)
 	if err != nil {
 		msg := fmt.Errorf("search failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	page, err := h.cursors.newPage(r, listing{
+		sort:   p.Sort,
+		limit:  p.Limit,
+		offset: p.Offset,
+		after:  p.After,
+		count:  len(actors),
+		total:  total,
+		last:   func() []interface{} { return actorKeyValues(p.Sort, actors[len(actors)-1]) },
+	})
+	if err != nil {
+		msg := fmt.Errorf("search failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	tag := listETag(total, len(actors), func(i int) (int, int) { return actors[i].Id, actors[i].Version })
+	if notModified(w, r, tag) {
+		return
+	}
+
 	renderResponse(w,
-		actors,
+		ListActorsResponse{
+			Items: actors,
+			Page:  page,
+		},
+		http.StatusOK)
+}
+
+// SearchActorsResponse is a page of the actors found by a search.
+type SearchActorsResponse struct {
+	Items []models.ActorHit `json:"items"`
+	Total int               `json:"total"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+	Next  string            `json:"next,omitempty"`
+}
+
+//	@Tags Actors
+//
+// @Description	search actors by name, typos are tolerated
+// @Produce		json
+// @Param		q			query		string	false	"Name to search for, the more terms match the higher the score"
+// @Param		fuzziness	query		string	false	"Typos tolerated in each term of q: 0, 1, 2 or AUTO"	default(AUTO)
+// @Param		gender		query		string	false	"M or F"
+// @Param		born_from	query		string	false	"Earliest birth date, 2006-01-02 format"
+// @Param		born_to		query		string	false	"Latest birth date, 2006-01-02 format"
+// @Param		page		query		int		false	"Page number"	default(1)
+// @Param		limit		query		int		false	"Page size, 1 to 100"	default(20)
+// @Success		200		{object}	SearchActorsResponse	"ok"
+// @Failure		400		{object}	ErrorResponse	"Bad request, fields holds the invalid parameters"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/actors/search [get]
+func (h *ActorHandler) fullTextSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	p := m.SearchActors{
+		Query:     q.Get("q"),
+		Fuzziness: q.Get("fuzziness"),
+		Gender:    q.Get("gender"),
+		BornFrom:  q.Get("born_from"),
+		BornTo:    q.Get("born_to"),
+	}
+
+	errs := m.FieldErrors{}
+
+	var err error
+	if p.Limit, err = parseInt(q, "limit", defaultLimit); err != nil {
+		errs.Add("limit", "must be a whole number")
+	}
+	if p.Page, err = parseInt(q, "page", 1); err != nil {
+		errs.Add("page", "must be a whole number")
+	}
+
+	if len(errs) > 0 {
+		msg := fmt.Errorf("invalid request %w", internal.WrapErrorf(errs, internal.ErrorCodeInvalidArgument, "query"))
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	res, err := h.svc.FullTextSearch(r.Context(), p)
+	if err != nil {
+		msg := fmt.Errorf("search failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	resp := SearchActorsResponse{
+		Items: res.Hits,
+		Total: res.Total,
+		Page:  p.Page,
+		Limit: p.Limit,
+	}
+
+	if p.Page*p.Limit < res.Total && (p.Page+1)*p.Limit <= m.MaxSearchWindow {
+		q.Set("page", strconv.Itoa(p.Page+1))
+		resp.Next = r.URL.Path + "?" + q.Encode()
+	}
+
+	renderResponse(w,
+		resp,
 		http.StatusOK)
 }
 
@@ -131,8 +266,10 @@ func (h *ActorHandler) search(w http.ResponseWriter, r *http.Request) {
 //
 // @Description	get one actors by id
 // @Param		id		path		int		true	"Actor ID"
+// @Param		If-None-Match	header	string	false	"ETag of the cached actor"
 // @Produce		json
 // @Success		200		{object}	models.Actor			"ok"
+// @Success		304		string		null				"not modified"
 // @Failure		400		{object}	internal.Error	"Bad request"
 // @Failure		404		{object}	internal.Error	"Resource not found"
 // @Failure		500		{object}	internal.Error	"Internal error"
@@ -140,13 +277,17 @@ func (h *ActorHandler) search(w http.ResponseWriter, r *http.Request) {
 func (h *ActorHandler) find(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	actor, err := h.svc.Find(id)
+	actor, err := h.svc.Find(r.Context(), id)
 	if err != nil {
 		msg := fmt.Errorf("find failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	if notModified(w, r, etag(actor.Version)) {
+		return
+	}
+
 	renderResponse(w,
 		actor,
 		http.StatusOK)
@@ -156,18 +297,21 @@ func (h *ActorHandler) find(w http.ResponseWriter, r *http.Request) {
 //
 // @Description	Update actor by id
 // @Param		id		path		int		true	"Actor ID"
+// @Param		If-Match	header	string	false	"ETag of the version being changed, without it the change is unconditional"
 // @Accept		json
 // @Produce		json
 // @Param		json	body		m.UpdateActor	true	"input data"
-// @Success		200		{object}	models.Actor			"ok"
+// @Success		200		string		null				"ok"
+// @Header		200		{string}	ETag	"ETag of the new version"
 // @Failure		400		{object}	internal.Error	"Bad request"
 // @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		412		{object}	internal.Error	"Precondition failed"
 // @Failure		500		{object}	internal.Error	"Internal error"
 // @Router		/actors/{id} [put]
 func (h *ActorHandler) update(w http.ResponseWriter, r *http.Request) {
 	var req m.UpdateActor
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		e := internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "json decoder")
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
 		msg := fmt.Errorf("invalid request %w", e)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -177,12 +321,69 @@ func (h *ActorHandler) update(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	err := h.svc.Update(id, req)
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	updated, err := h.svc.Update(r.Context(), id, req, version)
 	if err != nil {
 		msg := fmt.Errorf("update failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updated))
+
 	renderResponse(w, &struct{}{}, http.StatusOK)
 }
+
+//	@Tags Actors
+//
+// @Description	Partially update actor by id with a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902)
+// @Param		id		path		int		true	"Actor ID"
+// @Param		If-Match	header	string	false	"ETag of the version being changed, without it the change is unconditional"
+// @Accept		application/merge-patch+json,application/json-patch+json
+// @Produce		json
+// @Param		json	body		object	true	"patch document"
+// @Success		200		{object}	models.Actor			"ok"
+// @Header		200		{string}	ETag	"ETag of the new version"
+// @Failure		400		{object}	internal.Error	"Bad request"
+// @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		412		{object}	internal.Error	"Precondition failed"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/actors/{id} [patch]
+func (h *ActorHandler) patch(w http.ResponseWriter, r *http.Request) {
+	req, err := readPatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	defer r.Body.Close()
+
+	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	actor, err := h.svc.Patch(r.Context(), id, req, version)
+	if err != nil {
+		msg := fmt.Errorf("patch failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	w.Header().Set("ETag", etag(actor.Version))
+
+	renderResponse(w,
+		actor,
+		http.StatusOK)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 
+	"filmoteka/internal"
 	"filmoteka/internal/app/models"
 	"filmoteka/internal/restapi/mock_restapi"
 	m "filmoteka/internal/restapi/models"
@@ -43,7 +44,7 @@ func TestHandler_ActorCreate(t *testing.T) {
 				BirthDate: "1995-01-12",
 			},
 			mockBehavior: func(r *mock_restapi.MockActorService, a m.CreateActor) {
-				r.EXPECT().Create(a).Return(testActor, nil)
+				r.EXPECT().Create(gomock.Any(), a).Return(testActor, nil)
 			},
 			expectedStatusCode:   201,
 			expectedResponseBody: `{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
@@ -65,7 +66,7 @@ func TestHandler_ActorCreate(t *testing.T) {
 				BirthDate: "1995-01-12",
 			},
 			mockBehavior: func(r *mock_restapi.MockActorService, a m.CreateActor) {
-				r.EXPECT().Create(a).Return(models.Actor{}, errors.New(`internal error`))
+				r.EXPECT().Create(gomock.Any(), a).Return(models.Actor{}, errors.New(`internal error`))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"error":"internal error"}`,
@@ -81,7 +82,7 @@ func TestHandler_ActorCreate(t *testing.T) {
 			r := mux.NewRouter()
 			svc := mock_restapi.NewMockActorService(c)
 			tt.mockBehavior(svc, tt.input)
-			NewActorHandler(svc).Register(r)
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
 
 			// Create Request
 			w := httptest.NewRecorder()
@@ -107,14 +108,17 @@ func TestHandler_ActorFind(t *testing.T) {
 		Name:      "Name 1",
 		Gender:    "M",
 		BirthDate: "1995-01-12",
+		Version:   3,
 	}
 
 	tests := []struct {
 		name                 string
 		inputBody            string
 		input                string
+		ifNoneMatch          string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedETag         string
 		expectedResponseBody string
 	}{
 		{
@@ -122,9 +126,34 @@ func TestHandler_ActorFind(t *testing.T) {
 			inputBody: ``,
 			input:     "1",
 			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
-				r.EXPECT().Find(id).Return(testActor, nil)
+				r.EXPECT().Find(gomock.Any(), id).Return(testActor, nil)
 			},
 			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
+		},
+		{
+			name:        "Not Modified",
+			inputBody:   ``,
+			input:       "1",
+			ifNoneMatch: `"2", "3"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(testActor, nil)
+			},
+			expectedStatusCode:   304,
+			expectedETag:         `"3"`,
+			expectedResponseBody: ``,
+		},
+		{
+			name:        "Modified",
+			inputBody:   ``,
+			input:       "1",
+			ifNoneMatch: `"2"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(testActor, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
 			expectedResponseBody: `{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
 		},
 		{
@@ -132,7 +161,7 @@ func TestHandler_ActorFind(t *testing.T) {
 			inputBody: ``,
 			input:     "1",
 			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
-				r.EXPECT().Find(id).Return(models.Actor{}, errors.New(`internal error`))
+				r.EXPECT().Find(gomock.Any(), id).Return(models.Actor{}, errors.New(`internal error`))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"error":"internal error"}`,
@@ -148,12 +177,15 @@ func TestHandler_ActorFind(t *testing.T) {
 			r := mux.NewRouter()
 			svc := mock_restapi.NewMockActorService(c)
 			tt.mockBehavior(svc, tt.input)
-			NewActorHandler(svc).Register(r)
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
 
 			// Create Request
 			w := httptest.NewRecorder()
 			reqUrl := "/actors/" + tt.input
 			req := httptest.NewRequest("GET", reqUrl, bytes.NewBufferString(""))
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -161,6 +193,7 @@ func TestHandler_ActorFind(t *testing.T) {
 			// Assert
 			// fmt.Println("Body :", w.Body.String())
 			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
 			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
 		})
 	}
@@ -186,27 +219,73 @@ func TestHandler_ActorSearch(t *testing.T) {
 		},
 	}
 
+	cursors := NewCursors([]byte("secret"))
+
+	nextCursor, _ := cursors.encode(cursor{Sort: "-birth_date", Values: []interface{}{"1995-02-12", 2}})
+
 	tests := []struct {
 		name                 string
-		inputBody            string
+		query                string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
 		{
-			name:      "Ok",
-			inputBody: ``,
+			name:  "Ok",
+			query: ``,
 			mockBehavior: func(r *mock_restapi.MockActorService) {
-				r.EXPECT().Search().Return(actors, nil)
+				r.EXPECT().Search(gomock.Any(), m.ListActors{Limit: 20}).Return(actors, 2, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `[{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"},{"id":2,"name":"Name 2","gender":"F","birth_date":"1995-02-12"}]`,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"},{"id":2,"name":"Name 2","gender":"F","birth_date":"1995-02-12"}],"total":2,"limit":20,"offset":0}`,
 		},
 		{
-			name:      "Service Error",
-			inputBody: ``,
+			name:  "Page",
+			query: `?limit=2&offset=2&sort=-birth_date&gender=F`,
+			mockBehavior: func(r *mock_restapi.MockActorService) {
+				r.EXPECT().Search(gomock.Any(), m.ListActors{
+					Limit:  2,
+					Offset: 2,
+					Sort:   []m.SortField{{Name: "birth_date", Desc: true}},
+					Gender: "F",
+				}).Return(actors, 6, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"},{"id":2,"name":"Name 2","gender":"F","birth_date":"1995-02-12"}],"total":6,"limit":2,"offset":2,"next":"/actors?gender=F\u0026limit=2\u0026offset=4\u0026sort=-birth_date","prev":"/actors?gender=F\u0026limit=2\u0026offset=0\u0026sort=-birth_date","next_cursor":"` + nextCursor + `"}`,
+		},
+		{
+			name:  "Cursor",
+			query: `?limit=2&gender=F&cursor=` + nextCursor,
 			mockBehavior: func(r *mock_restapi.MockActorService) {
-				r.EXPECT().Search().Return(actors, errors.New(`internal error`))
+				r.EXPECT().Search(gomock.Any(), m.ListActors{
+					Limit:  2,
+					Sort:   []m.SortField{{Name: "birth_date", Desc: true}},
+					After:  []interface{}{"1995-02-12", int64(2)},
+					Gender: "F",
+				}).Return(actors, 6, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Name 1","gender":"M","birth_date":"1995-01-12"},{"id":2,"name":"Name 2","gender":"F","birth_date":"1995-02-12"}],"total":6,"limit":2,"offset":0,"next":"/actors?cursor=` + nextCursor + `\u0026gender=F\u0026limit=2","next_cursor":"` + nextCursor + `"}`,
+		},
+		{
+			name:                 "Forged Cursor",
+			query:                `?cursor=eyJzIjoiIiwidiI6WzFdfQ.c2lnbmF0dXJl`,
+			mockBehavior:         func(r *mock_restapi.MockActorService) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request invalid cursor"}`,
+		},
+		{
+			name:                 "Wrong Input",
+			query:                `?limit=ten`,
+			mockBehavior:         func(r *mock_restapi.MockActorService) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request invalid limit: strconv.Atoi: parsing \"ten\": invalid syntax"}`,
+		},
+		{
+			name:  "Service Error",
+			query: ``,
+			mockBehavior: func(r *mock_restapi.MockActorService) {
+				r.EXPECT().Search(gomock.Any(), gomock.Any()).Return(nil, 0, errors.New(`internal error`))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"error":"internal error"}`,
@@ -221,12 +300,124 @@ func TestHandler_ActorSearch(t *testing.T) {
 
 			// Create Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/actors", bytes.NewBufferString(""))
+			req := httptest.NewRequest("GET", "/actors"+tt.query, bytes.NewBufferString(""))
 
 			r := mux.NewRouter()
 			svc := mock_restapi.NewMockActorService(c)
 			tt.mockBehavior(svc)
-			NewActorHandler(svc).Register(r)
+			NewActorHandler(svc, cursors).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_ActorFullTextSearch(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockActorService, p m.SearchActors)
+
+	result := models.ActorSearchResult{
+		Total: 3,
+		Hits: []models.ActorHit{
+			{
+				Actor: models.Actor{
+					Id:        1,
+					Name:      "Johnny Depp",
+					Gender:    "M",
+					BirthDate: "1963-06-09",
+				},
+				Score: 2.5,
+			},
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		query                string
+		input                m.SearchActors
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "?q=jonny+dep&fuzziness=2&gender=M&born_from=1960-01-01&born_to=1969-12-31&limit=1",
+			input: m.SearchActors{
+				Query:     "jonny dep",
+				Fuzziness: "2",
+				Gender:    "M",
+				BornFrom:  "1960-01-01",
+				BornTo:    "1969-12-31",
+				Limit:     1,
+				Page:      1,
+			},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.SearchActors) {
+				r.EXPECT().FullTextSearch(gomock.Any(), p).Return(result, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Johnny Depp","gender":"M","birth_date":"1963-06-09","score":2.5}],"total":3,"page":1,"limit":1,` +
+				`"next":"/actors/search?born_from=1960-01-01\u0026born_to=1969-12-31\u0026fuzziness=2\u0026gender=M\u0026limit=1\u0026page=2\u0026q=jonny+dep"}`,
+		},
+		{
+			name:  "Last Page",
+			query: "?q=depp&page=3&limit=1",
+			input: m.SearchActors{Query: "depp", Limit: 1, Page: 3},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.SearchActors) {
+				r.EXPECT().FullTextSearch(gomock.Any(), p).Return(result, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Johnny Depp","gender":"M","birth_date":"1963-06-09","score":2.5}],"total":3,"page":3,"limit":1}`,
+		},
+		{
+			name:                 "Invalid Parameters",
+			query:                "?limit=ten&page=last",
+			mockBehavior:         func(r *mock_restapi.MockActorService, p m.SearchActors) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid limit, page","fields":{"limit":"must be a whole number","page":"must be a whole number"}}`,
+		},
+		{
+			name:  "Invalid Values",
+			query: "?born_from=06/09/1963",
+			input: m.SearchActors{BornFrom: "06/09/1963", Limit: 20, Page: 1},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.SearchActors) {
+				r.EXPECT().FullTextSearch(gomock.Any(), p).Return(models.ActorSearchResult{},
+					internal.WrapErrorf(m.FieldErrors{"born_from": "must be a date in 2006-01-02 format"},
+						internal.ErrorCodeInvalidArgument, "validate search"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"search failed: validate search: invalid born_from","fields":{"born_from":"must be a date in 2006-01-02 format"}}`,
+		},
+		{
+			name:  "Service Error",
+			query: "?q=depp",
+			input: m.SearchActors{Query: "depp", Limit: 20, Page: 1},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.SearchActors) {
+				r.EXPECT().FullTextSearch(gomock.Any(), p).Return(models.ActorSearchResult{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/actors/search"+tt.query, bytes.NewBufferString(""))
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockActorService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -246,6 +437,7 @@ func TestHandler_ActorDelete(t *testing.T) {
 		name                 string
 		input                string
 		inputBody            string
+		ifMatch              string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
@@ -255,17 +447,49 @@ func TestHandler_ActorDelete(t *testing.T) {
 			input:     "1",
 			inputBody: ``,
 			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
-				r.EXPECT().Delete(id).Return(nil)
+				r.EXPECT().Delete(gomock.Any(), id, 0).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "If-Match",
+			input:     "1",
+			inputBody: ``,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 2).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{}`,
 		},
+		{
+			name:      "Precondition Failed",
+			input:     "1",
+			inputBody: ``,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 2).Return(
+					internal.NewErrorf(internal.ErrorCodePreconditionFailed, "delete actor: version 2 is not current"))
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"delete failed: delete actor: version 2 is not current"}`,
+		},
+		{
+			name:                 "Weak ETag",
+			input:                "1",
+			inputBody:            ``,
+			ifMatch:              `W/"2"`,
+			mockBehavior:         func(r *mock_restapi.MockActorService, id string) {},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"invalid request weak etag W/\"2\" does not match"}`,
+		},
 		{
 			name:      "Service Error",
 			input:     "1",
 			inputBody: ``,
 			mockBehavior: func(r *mock_restapi.MockActorService, id string) {
-				r.EXPECT().Delete(id).Return(errors.New(`internal error`))
+				r.EXPECT().Delete(gomock.Any(), id, 0).Return(errors.New(`internal error`))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"error":"internal error"}`,
@@ -281,12 +505,15 @@ func TestHandler_ActorDelete(t *testing.T) {
 			r := mux.NewRouter()
 			svc := mock_restapi.NewMockActorService(c)
 			tt.mockBehavior(svc, tt.input)
-			NewActorHandler(svc).Register(r)
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
 
 			// Create Request
 			w := httptest.NewRecorder()
 			reqUrl := "/actors/" + tt.input
 			req := httptest.NewRequest("DELETE", reqUrl, bytes.NewBufferString(""))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -312,8 +539,10 @@ func TestHandler_ActorUpdate(t *testing.T) {
 		name                 string
 		input                m.UpdateActor
 		inputBody            string
+		ifMatch              string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedETag         string
 		expectedResponseBody string
 	}{
 		{
@@ -321,17 +550,42 @@ func TestHandler_ActorUpdate(t *testing.T) {
 			input:     testActor,
 			inputBody: `{"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
 			mockBehavior: func(r *mock_restapi.MockActorService, a m.UpdateActor, id string) {
-				r.EXPECT().Update(id, a).Return(nil)
+				r.EXPECT().Update(gomock.Any(), id, a, 0).Return(2, nil)
 			},
 			expectedStatusCode:   200,
+			expectedETag:         `"2"`,
 			expectedResponseBody: `{}`,
 		},
+		{
+			name:      "If-Match",
+			input:     testActor,
+			inputBody: `{"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, a m.UpdateActor, id string) {
+				r.EXPECT().Update(gomock.Any(), id, a, 2).Return(3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "Precondition Failed",
+			input:     testActor,
+			inputBody: `{"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockActorService, a m.UpdateActor, id string) {
+				r.EXPECT().Update(gomock.Any(), id, a, 2).Return(0,
+					internal.NewErrorf(internal.ErrorCodePreconditionFailed, "update actor: version 2 is not current"))
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"update failed: update actor: version 2 is not current"}`,
+		},
 		{
 			name:      "Service Error",
 			input:     testActor,
 			inputBody: `{"name":"Name 1","gender":"M","birth_date":"1995-01-12"}`,
 			mockBehavior: func(r *mock_restapi.MockActorService, a m.UpdateActor, id string) {
-				r.EXPECT().Update(id, a).Return(errors.New(`internal error`))
+				r.EXPECT().Update(gomock.Any(), id, a, 0).Return(0, errors.New(`internal error`))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"error":"internal error"}`,
@@ -348,12 +602,95 @@ func TestHandler_ActorUpdate(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", "/actors/1",
 				bytes.NewBufferString(tt.inputBody))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			r := mux.NewRouter()
 			svc := mock_restapi.NewMockActorService(c)
 			// id := mux.Vars(req)["id"]
 			tt.mockBehavior(svc, tt.input, "1")
-			NewActorHandler(svc).Register(r)
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_ActorPatch(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockActorService, p m.Patch, id string)
+
+	testActor := models.Actor{
+		Id:        1,
+		Name:      "Name 2",
+		Gender:    "M",
+		BirthDate: "1995-01-12",
+	}
+
+	tests := []struct {
+		name                 string
+		contentType          string
+		inputBody            string
+		input                m.Patch
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Merge Patch",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"name":"Name 2"}`,
+			input:       m.Patch{Type: m.MergePatch, Body: []byte(`{"name":"Name 2"}`)},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 0).Return(testActor, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"name":"Name 2","gender":"M","birth_date":"1995-01-12"}`,
+		},
+		{
+			name:        "JSON Patch",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op":"replace","path":"/name","value":"Name 2"}]`,
+			input:       m.Patch{Type: m.JSONPatch, Body: []byte(`[{"op":"replace","path":"/name","value":"Name 2"}]`)},
+			mockBehavior: func(r *mock_restapi.MockActorService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 0).Return(testActor, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"name":"Name 2","gender":"M","birth_date":"1995-01-12"}`,
+		},
+		{
+			name:                 "Unsupported Content Type",
+			contentType:          "text/plain",
+			inputBody:            `name=Name 2`,
+			mockBehavior:         func(r *mock_restapi.MockActorService, p m.Patch, id string) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request unsupported content type text/plain"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/actors/1",
+				bytes.NewBufferString(tt.inputBody))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockActorService(c)
+			tt.mockBehavior(svc, tt.input, "1")
+			NewActorHandler(svc, NewCursors([]byte("secret"))).Register(r)
 
 			// Make Request
 			r.ServeHTTP(w, req)
@@ -0,0 +1,44 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+)
+
+//go:generate mockgen -source=breaker.go -destination=mock_restapi/mockbreaker.go
+
+// CircuitBreakerService
+type CircuitBreakerService interface {
+	Stats() models.BreakerStats
+}
+
+// CircuitBreakerHandler
+type CircuitBreakerHandler struct {
+	svc CircuitBreakerService
+}
+
+// NewCircuitBreakerHandler ...
+func NewCircuitBreakerHandler(svc CircuitBreakerService) *CircuitBreakerHandler {
+	return &CircuitBreakerHandler{
+		svc: svc,
+	}
+}
+
+func (h *CircuitBreakerHandler) Register(r *mux.Router) {
+	r.HandleFunc("/search/breaker", h.stats).Methods(http.MethodGet)
+}
+
+//	@Tags Search
+//
+// @Description	get the state of the circuit breaker guarding the search index, searches are served by Postgres while it is open
+// @Produce		json
+// @Success		200		{object}	models.BreakerStats	"ok"
+// @Router		/search/breaker [get]
+func (h *CircuitBreakerHandler) stats(w http.ResponseWriter, r *http.Request) {
+	renderResponse(w,
+		h.svc.Stats(),
+		http.StatusOK)
+}
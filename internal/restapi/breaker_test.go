@@ -0,0 +1,79 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+)
+
+func TestHandler_CircuitBreakerStats(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockCircuitBreakerService)
+
+	openedAt := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Closed",
+			mockBehavior: func(r *mock_restapi.MockCircuitBreakerService) {
+				r.EXPECT().Stats().Return(models.BreakerStats{Name: "elasticsearch", State: models.BreakerClosed, Retries: 2})
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"name":"elasticsearch","state":"closed","failures":0,"opens":0,"rejected":0,"retries":2}`,
+		},
+		{
+			name: "Open",
+			mockBehavior: func(r *mock_restapi.MockCircuitBreakerService) {
+				r.EXPECT().Stats().Return(models.BreakerStats{
+					Name:     "elasticsearch",
+					State:    models.BreakerOpen,
+					Failures: 5,
+					OpenedAt: &openedAt,
+					Opens:    1,
+					Rejected: 7,
+					Retries:  4,
+				})
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"name":"elasticsearch","state":"open","failures":5,"opened_at":"2024-01-02T15:04:05Z",` +
+				`"opens":1,"rejected":7,"retries":4}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockCircuitBreakerService(c)
+			tt.mockBehavior(svc)
+			NewCircuitBreakerHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/search/breaker", bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+//go:generate mockgen -source=cast.go -destination=mock_restapi/mockcast.go
+
+// CastService
+type CastService interface {
+	Create(ctx context.Context, filmId string, c m.CreateCast) (models.CastMember, error)
+	Delete(ctx context.Context, filmId, actorId string) error
+	Update(ctx context.Context, filmId, actorId string, c m.UpdateCast) error
+	FilmCast(ctx context.Context, filmId string) ([]models.CastMember, error)
+	ActorFilms(ctx context.Context, actorId string) ([]models.Role, error)
+}
+
+// CastHandler
+type CastHandler struct {
+	svc CastService
+}
+
+// NewCastHandler ...
+func NewCastHandler(svc CastService) *CastHandler {
+	return &CastHandler{
+		svc: svc,
+	}
+}
+
+func (h *CastHandler) Register(r *mux.Router) {
+	r.HandleFunc("/films/{id}/cast", h.create).Methods(http.MethodPost)
+	r.HandleFunc("/films/{id}/cast", h.filmCast).Methods(http.MethodGet)
+	r.HandleFunc("/films/{id}/cast/{actor_id}", h.update).Methods(http.MethodPut)
+	r.HandleFunc("/films/{id}/cast/{actor_id}", h.delete).Methods(http.MethodDelete)
+	r.HandleFunc("/actors/{id}/films", h.actorFilms).Methods(http.MethodGet)
+}
+
+//	@Tags Cast
+//
+// @Description	add an actor to the cast of a film
+// @Param		id		path		int		true	"Film ID"
+// @Accept		json
+// @Produce		json
+// @Param		json	body		m.CreateCast	true	"input data"
+// @Success		201		{object}	models.CastMember	"ok"
+// @Failure		400		{object}	internal.Error	"Bad request"
+// @Failure		404		{object}	internal.Error	"Film or actor not found"
+// @Failure		409		{object}	internal.Error	"Actor already in cast"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/{id}/cast [post]
+func (h *CastHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req m.CreateCast
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
+		msg := fmt.Errorf("invalid request %w", e)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	defer r.Body.Close()
+
+	filmId := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	cm, err := h.svc.Create(r.Context(), filmId, req)
+	if err != nil {
+		msg := fmt.Errorf("create failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		cm,
+		http.StatusCreated)
+}
+
+//	@Tags Cast
+//
+// @Description	get the cast of a film
+// @Param		id		path		int		true	"Film ID"
+// @Produce		json
+// @Success		200		{object}	[]models.CastMember	"ok"
+// @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/{id}/cast [get]
+func (h *CastHandler) filmCast(w http.ResponseWriter, r *http.Request) {
+	filmId := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	cast, err := h.svc.FilmCast(r.Context(), filmId)
+	if err != nil {
+		msg := fmt.Errorf("find failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		cast,
+		http.StatusOK)
+}
+
+//	@Tags Cast
+//
+// @Description	update the role of an actor in a film
+// @Param		id			path		int		true	"Film ID"
+// @Param		actor_id	path		int		true	"Actor ID"
+// @Accept		json
+// @Produce		json
+// @Param		json	body		m.UpdateCast	true	"input data"
+// @Success		200		string		null			"ok"
+// @Failure		400		{object}	internal.Error	"Bad request"
+// @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/{id}/cast/{actor_id} [put]
+func (h *CastHandler) update(w http.ResponseWriter, r *http.Request) {
+	var req m.UpdateCast
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
+		msg := fmt.Errorf("invalid request %w", e)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	defer r.Body.Close()
+
+	vars := mux.Vars(r) // NOTE: Safe to ignore error, because they're always defined.
+
+	if err := h.svc.Update(r.Context(), vars["id"], vars["actor_id"], req); err != nil {
+		msg := fmt.Errorf("update failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w, &struct{}{}, http.StatusOK)
+}
+
+//	@Tags Cast
+//
+// @Description	remove an actor from the cast of a film
+// @Param		id			path		int		true	"Film ID"
+// @Param		actor_id	path		int		true	"Actor ID"
+// @Produce		json
+// @Success		200		string		null			"ok"
+// @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/{id}/cast/{actor_id} [delete]
+func (h *CastHandler) delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r) // NOTE: Safe to ignore error, because they're always defined.
+
+	if err := h.svc.Delete(r.Context(), vars["id"], vars["actor_id"]); err != nil {
+		msg := fmt.Errorf("delete failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w, struct{}{}, http.StatusOK)
+}
+
+//	@Tags Cast
+//
+// @Description	get the films an actor played in
+// @Param		id		path		int		true	"Actor ID"
+// @Produce		json
+// @Success		200		{object}	[]models.Role	"ok"
+// @Failure		404		{object}	internal.Error	"Resource not found"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/actors/{id}/films [get]
+func (h *CastHandler) actorFilms(w http.ResponseWriter, r *http.Request) {
+	actorId := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	roles, err := h.svc.ActorFilms(r.Context(), actorId)
+	if err != nil {
+		msg := fmt.Errorf("find failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		roles,
+		http.StatusOK)
+}
@@ -0,0 +1,334 @@
+package restapi
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/app/service"
+	"filmoteka/internal/restapi/mock_restapi"
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestHandler_CastCreate(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockCastService, filmId string, c m.CreateCast)
+
+	testCast := models.CastMember{
+		ActorId:       2,
+		Name:          "Name 2",
+		CharacterName: "Character 2",
+		BillingOrder:  1,
+	}
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                m.CreateCast
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"actor_id":2,"character_name":"Character 2","billing_order":1}`,
+			input: m.CreateCast{
+				ActorId:       2,
+				CharacterName: "Character 2",
+				BillingOrder:  1,
+			},
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId string, c m.CreateCast) {
+				r.EXPECT().Create(gomock.Any(), filmId, c).Return(testCast, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"actor_id":2,"name":"Name 2","character_name":"Character 2","billing_order":1}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            "",
+			input:                m.CreateCast{},
+			mockBehavior:         func(r *mock_restapi.MockCastService, filmId string, c m.CreateCast) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request json decoder: EOF"}`,
+		},
+		{
+			name:      "Already In Cast",
+			inputBody: `{"actor_id":2,"character_name":"Character 2","billing_order":1}`,
+			input: m.CreateCast{
+				ActorId:       2,
+				CharacterName: "Character 2",
+				BillingOrder:  1,
+			},
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId string, c m.CreateCast) {
+				r.EXPECT().Create(gomock.Any(), filmId, c).Return(models.CastMember{},
+					internal.NewErrorf(internal.ErrorCodeUniqueConstraints, "insert cast"))
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"error":"create failed: insert cast"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockCastService(c)
+			tt.mockBehavior(svc, "1", tt.input)
+			NewCastHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/films/1/cast",
+				bytes.NewBufferString(tt.inputBody))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmCast(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockCastService, filmId string)
+
+	cast := []models.CastMember{
+		{
+			ActorId:       1,
+			Name:          "Name 1",
+			CharacterName: "Character 1",
+			BillingOrder:  1,
+		},
+		{
+			ActorId:       2,
+			Name:          "Name 2",
+			CharacterName: "Character 2",
+			BillingOrder:  2,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		input                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			input: "1",
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId string) {
+				r.EXPECT().FilmCast(gomock.Any(), filmId).Return(cast, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"actor_id":1,"name":"Name 1","character_name":"Character 1","billing_order":1},{"actor_id":2,"name":"Name 2","character_name":"Character 2","billing_order":2}]`,
+		},
+		{
+			name:  "Film Not Found",
+			input: "1",
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId string) {
+				r.EXPECT().FilmCast(gomock.Any(), filmId).Return(nil, internal.NewErrorf(internal.ErrorCodeNotFound, "find films"))
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"error":"find failed: find films"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockCastService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewCastHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			reqUrl := "/films/" + tt.input + "/cast"
+			req := httptest.NewRequest("GET", reqUrl, bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_CastDelete(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockCastService, filmId, actorId string)
+
+	tests := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok",
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId, actorId string) {
+				r.EXPECT().Delete(gomock.Any(), filmId, actorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(r *mock_restapi.MockCastService, filmId, actorId string) {
+				r.EXPECT().Delete(gomock.Any(), filmId, actorId).Return(errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockCastService(c)
+			tt.mockBehavior(svc, "1", "2")
+			NewCastHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/films/1/cast/2", bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_ActorFilms(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockCastService, actorId string)
+
+	roles := []models.Role{
+		{
+			FilmId:        1,
+			Name:          "Film 1",
+			ReleaseYear:   2003,
+			CharacterName: "Character 1",
+			BillingOrder:  1,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		input                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			input: "2",
+			mockBehavior: func(r *mock_restapi.MockCastService, actorId string) {
+				r.EXPECT().ActorFilms(gomock.Any(), actorId).Return(roles, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"film_id":1,"name":"Film 1","release_year":2003,"character_name":"Character 1","billing_order":1}]`,
+		},
+		{
+			name:  "Service Error",
+			input: "2",
+			mockBehavior: func(r *mock_restapi.MockCastService, actorId string) {
+				r.EXPECT().ActorFilms(gomock.Any(), actorId).Return(nil, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockCastService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewCastHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			reqUrl := "/actors/" + tt.input + "/films"
+			req := httptest.NewRequest("GET", reqUrl, bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_CastBillingOrder(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		target             string
+		inputBody          string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Create",
+			method:             "POST",
+			target:             "/films/1/cast",
+			inputBody:          `{"actor_id":2,"character_name":"Character 2","billing_order":32768}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Update",
+			method:             "PUT",
+			target:             "/films/1/cast/2",
+			inputBody:          `{"character_name":"Character 2","billing_order":65535}`,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// NOTE: Rejected by the service before reaching the repository, whose column is a smallint.
+			r := mux.NewRouter()
+			NewCastHandler(service.NewCastService(nil)).Register(r)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package restapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// Cursors encodes and decodes the opaque tokens used for paging listings. Tokens are signed so
+// that clients can't forge arbitrary keyset queries.
+type Cursors struct {
+	secret []byte
+}
+
+// NewCursors ...
+func NewCursors(secret []byte) *Cursors {
+	return &Cursors{
+		secret: secret,
+	}
+}
+
+// cursor is the content of a token: the position of the last row of a page.
+type cursor struct {
+	// Sort is the sort the cursor was created for, in query parameter format.
+	Sort string `json:"s"`
+	// Values are the sort key values of the last row, see m.StableSort.
+	Values []interface{} `json:"v"`
+	// Backend is the search backend which served the page, search cursors only.
+	Backend string `json:"b,omitempty"`
+}
+
+// encode returns the signed token for cur.
+func (c *Cursors) encode(cur cursor) (string, error) {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", internal.WrapErrorf(err, internal.ErrorCodeUnknown, "json.Marshal")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// decode verifies the token and returns its content.
+func (c *Cursors) decode(token string) (cursor, error) {
+	invalid := func(err error) (cursor, error) {
+		return cursor{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid cursor")
+	}
+
+	encPayload, encMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return invalid(nil)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return invalid(err)
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return invalid(err)
+	}
+
+	if !hmac.Equal(mac, c.sign(payload)) {
+		return invalid(nil)
+	}
+
+	var cur cursor
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&cur); err != nil {
+		return invalid(err)
+	}
+
+	return cur, nil
+}
+
+func (c *Cursors) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write(payload)
+
+	return h.Sum(nil)
+}
+
+// formatSort returns sort in query parameter format, the reverse of parseSort.
+func formatSort(sort []m.SortField) string {
+	fields := make([]string, len(sort))
+	for i, s := range sort {
+		if s.Desc {
+			fields[i] = "-" + s.Name
+		} else {
+			fields[i] = s.Name
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// keyValues converts the values of a decoded cursor back to the types of the sort keys, numbers
+// are decoded as json.Number; floatKeys lists the keys that are not integers.
+func keyValues(sort []m.SortField, values []interface{}, floatKeys ...string) ([]interface{}, error) {
+	sort = m.StableSort(sort)
+	if len(values) != len(sort) {
+		return nil, internal.NewErrorf(internal.ErrorCodeInvalidArgument, "invalid cursor")
+	}
+
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			res[i] = v
+			continue
+		}
+
+		var err error
+
+		res[i], err = n.Int64()
+		for _, k := range floatKeys {
+			if sort[i].Name == k {
+				var f float64
+				f, err = strconv.ParseFloat(n.String(), 32)
+				res[i] = float32(f)
+			}
+		}
+		if err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid cursor")
+		}
+	}
+
+	return res, nil
+}
+
+// filmKeyValues returns the sort key values of f.
+func filmKeyValues(sort []m.SortField, f models.Film) []interface{} {
+	sort = m.StableSort(sort)
+
+	res := make([]interface{}, len(sort))
+	for i, s := range sort {
+		switch s.Name {
+		case "id":
+			res[i] = f.Id
+		case "name":
+			res[i] = f.Name
+		case "release_year":
+			res[i] = f.ReleaseYear
+		case "rating":
+			res[i] = f.Rating
+		}
+	}
+
+	return res
+}
+
+// actorKeyValues returns the sort key values of a.
+func actorKeyValues(sort []m.SortField, a models.Actor) []interface{} {
+	sort = m.StableSort(sort)
+
+	res := make([]interface{}, len(sort))
+	for i, s := range sort {
+		switch s.Name {
+		case "id":
+			res[i] = a.Id
+		case "name":
+			res[i] = a.Name
+		case "gender":
+			res[i] = a.Gender
+		case "birth_date":
+			res[i] = a.BirthDate
+		}
+	}
+
+	return res
+}
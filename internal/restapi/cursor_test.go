@@ -0,0 +1,167 @@
+package restapi
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert"
+
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestCursors_ListFilms(t *testing.T) {
+	cursors := NewCursors([]byte("secret"))
+
+	token, _ := cursors.encode(cursor{Sort: "-rating", Values: []interface{}{8.5, 3}})
+	short, _ := cursors.encode(cursor{Sort: "-rating", Values: []interface{}{8.5}})
+	other, _ := NewCursors([]byte("other secret")).encode(cursor{Sort: "-rating", Values: []interface{}{8.5, 3}})
+	forged, _ := cursors.encode(cursor{Sort: "-rating", Values: []interface{}{9.5, 3}})
+	// NOTE: 7.3 isn't exact in float32, the rating must come back as the film holds it.
+	inexact, _ := cursors.encode(cursor{
+		Sort:   "-rating",
+		Values: filmKeyValues([]m.SortField{{Name: "rating", Desc: true}}, models.Film{Id: 3, Rating: 7.3}),
+	})
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name          string
+		query         string
+		expected      m.ListFilms
+		expectedError string
+	}{
+		{
+			name:  "OK",
+			query: "cursor=" + token,
+			expected: m.ListFilms{
+				Limit: defaultLimit,
+				Sort:  []m.SortField{{Name: "rating", Desc: true}},
+				After: []interface{}{float32(8.5), int64(3)},
+			},
+		},
+		{
+			name:  "Same Sort",
+			query: "sort=-rating&cursor=" + token,
+			expected: m.ListFilms{
+				Limit: defaultLimit,
+				Sort:  []m.SortField{{Name: "rating", Desc: true}},
+				After: []interface{}{float32(8.5), int64(3)},
+			},
+		},
+		{
+			name:  "Inexact Rating",
+			query: "cursor=" + inexact,
+			expected: m.ListFilms{
+				Limit: defaultLimit,
+				Sort:  []m.SortField{{Name: "rating", Desc: true}},
+				After: []interface{}{float32(7.3), int64(3)},
+			},
+		},
+		{
+			name:          "Tampered Signature",
+			query:         "cursor=" + payload + "." + strings.Repeat("A", len(signature)),
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "Tampered Payload",
+			query:         "cursor=" + forgedPayload + "." + signature,
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "Other Secret",
+			query:         "cursor=" + other,
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "Malformed",
+			query:         "cursor=" + payload,
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "Different Sort",
+			query:         "sort=name&cursor=" + token,
+			expectedError: "sort does not match cursor",
+		},
+		{
+			name:          "Values Mismatch",
+			query:         "cursor=" + short,
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "With Offset",
+			query:         "offset=20&cursor=" + token,
+			expectedError: "cursor and offset are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+
+			p, err := cursors.parseListFilms(q)
+			if err == nil {
+				err = p.Validate()
+			}
+
+			if tt.expectedError != "" {
+				assert.NotEqual(t, err, nil)
+				assert.Equal(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, p, tt.expected)
+		})
+	}
+}
+
+func TestCursors_SearchFilms(t *testing.T) {
+	cursors := NewCursors([]byte("secret"))
+
+	token, _ := cursors.encode(cursor{Sort: "-score", Values: []interface{}{1.5, 3}})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedErrors m.FieldErrors
+	}{
+		{
+			name:  "OK",
+			query: "q=pirates&cursor=" + token,
+		},
+		{
+			name:           "Different Sort",
+			query:          "q=pirates&sort=-rating&cursor=" + token,
+			expectedErrors: m.FieldErrors{"cursor": "must be the next_cursor of a search with the same sort"},
+		},
+		{
+			name:           "With Page",
+			query:          "q=pirates&page=2&cursor=" + token,
+			expectedErrors: m.FieldErrors{"page": "must be 1 with search_after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+
+			p, err := cursors.parseSearchFilms(q)
+			if err == nil {
+				err = p.Validate()
+			}
+
+			if tt.expectedErrors == nil {
+				assert.Equal(t, err, nil)
+				return
+			}
+
+			var fields m.FieldErrors
+			assert.Equal(t, errors.As(err, &fields), true)
+			assert.Equal(t, fields, tt.expectedErrors)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package restapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"filmoteka/internal"
+)
+
+// etag returns the strong entity tag of a record at version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// listETag returns the weak entity tag of a page of a listing, it changes whenever one of the n
+// records of the page or the total changes.
+func listETag(total, n int, item func(i int) (id, version int)) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(total)))
+	for i := 0; i < n; i++ {
+		id, version := item(i)
+		h.Write([]byte("," + strconv.Itoa(id) + ":" + strconv.Itoa(version)))
+	}
+
+	return `W/"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// notModified sets the ETag header and, when the If-None-Match header matches tag, writes a
+// 304 response. Handlers must not write anything else when it returns true.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	// NOTE: If-None-Match uses the weak comparison, see RFC 9110 section 13.1.2.
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(tag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns the version required by the If-Match header, 0 when the header is missing or
+// is `*` which means any current version.
+func ifMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return 0, internal.NewErrorf(internal.ErrorCodeInvalidArgument, "multiple etags in If-Match are not supported")
+	}
+
+	// NOTE: If-Match uses the strong comparison, a weak tag never matches.
+	if strings.HasPrefix(header, "W/") {
+		return 0, internal.NewErrorf(internal.ErrorCodePreconditionFailed, "weak etag %s does not match", header)
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || header != etag(version) {
+		return 0, internal.NewErrorf(internal.ErrorCodePreconditionFailed, "etag %s does not match", header)
+	}
+
+	return version, nil
+}
@@ -19,44 +19,45 @@ import (
 type FilmService interface {
 	// By(args internal.SearchParams) (internal.SearchResults, error)
 	Create(ctx context.Context, f m.CreateFilm) (models.Film, error)
-	Delete(ctx context.Context, id string) error
-	Search(
-		ctx context.Context,
-		name string,
-		description string,
-		releaseYear uint16,
-		rating float32,
-	) ([]models.Film, error)
-	FindAll() ([]models.Film, error)
-	Find(id string) (models.Film, error)
-	Update(ctx context.Context, id string, f m.UpdateFilm) error
+	Delete(ctx context.Context, id string, version int) error
+	Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error)
+	Similar(ctx context.Context, id string, p m.SimilarFilms) ([]models.FilmHit, error)
+	FindAll(ctx context.Context, p m.ListFilms) ([]models.Film, int, error)
+	Find(ctx context.Context, id string) (models.Film, error)
+	Update(ctx context.Context, id string, f m.UpdateFilm, version int) (int, error)
+	Patch(ctx context.Context, id string, p m.Patch, version int) (models.Film, error)
 }
 
 // FilmHandler ...
 type FilmHandler struct {
-	svc FilmService
+	svc     FilmService
+	cursors *Cursors
 }
 
 // NewFilmHandler ...
-func NewFilmHandler(svc FilmService) *FilmHandler {
+func NewFilmHandler(svc FilmService, cursors *Cursors) *FilmHandler {
 	return &FilmHandler{
-		svc: svc,
+		svc:     svc,
+		cursors: cursors,
 	}
 }
 
 func (h *FilmHandler) Register(r *mux.Router) {
 	r.HandleFunc("/films", h.create).Methods(http.MethodPost)
 	r.HandleFunc("/films/search", h.search).Methods(http.MethodGet)
+	r.HandleFunc("/films/search", h.searchJSON).Methods(http.MethodPost)
 	r.HandleFunc("/films", h.findAll).Methods(http.MethodGet)
 	r.HandleFunc("/films/{id}", h.find).Methods(http.MethodGet)
+	r.HandleFunc("/films/{id}/similar", h.similar).Methods(http.MethodGet)
 	r.HandleFunc("/films/{id}", h.update).Methods(http.MethodPut)
+	r.HandleFunc("/films/{id}", h.patch).Methods(http.MethodPatch)
 	r.HandleFunc("/films/{id}", h.delete).Methods(http.MethodDelete)
 }
 
 func (h *FilmHandler) create(w http.ResponseWriter, r *http.Request) {
 	var req m.CreateFilm
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		e := internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "json decoder")
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
 		msg := fmt.Errorf("invalid request %w", e)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -80,7 +81,14 @@ func (h *FilmHandler) create(w http.ResponseWriter, r *http.Request) {
 func (h *FilmHandler) delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	if err := h.svc.Delete(r.Context(), id); err != nil {
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id, version); err != nil {
 		msg := fmt.Errorf("delete failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -94,66 +102,69 @@ type ReadFilmsResponse struct {
 	Film models.Film `json:"film"`
 }
 
-type SearchTasksRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	ReleaseYear uint16  `json:"release_year"`
-	Rating      float32 `json:"rating"`
+// ListFilmsResponse defines the response returned back after listing films.
+type ListFilmsResponse struct {
+	Items []models.Film `json:"items"`
+	Page
 }
 
-func (h *FilmHandler) search(w http.ResponseWriter, r *http.Request) {
-	var req SearchTasksRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		renderErrorResponse(
-			w,
-			"invalid request",
-			internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "json decoder"))
+func (h *FilmHandler) findAll(w http.ResponseWriter, r *http.Request) {
+	p, err := h.cursors.parseListFilms(r.URL.Query())
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
-	defer r.Body.Close()
-
-	films, err := h.svc.Search(
-		r.Context(),
-		req.Name,
-		req.Description,
-		req.ReleaseYear,
-		req.Rating,
-	)
+	films, total, err := h.svc.FindAll(r.Context(), p)
 	if err != nil {
-		msg := fmt.Errorf("search failed: %w", err)
+		msg := fmt.Errorf("find failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
-	renderResponse(w,
-		films,
-		http.StatusOK)
-}
-
-func (h *FilmHandler) findAll(w http.ResponseWriter, r *http.Request) {
-	film, err := h.svc.FindAll()
+	page, err := h.cursors.newPage(r, listing{
+		sort:   p.Sort,
+		limit:  p.Limit,
+		offset: p.Offset,
+		after:  p.After,
+		count:  len(films),
+		total:  total,
+		last:   func() []interface{} { return filmKeyValues(p.Sort, films[len(films)-1]) },
+	})
 	if err != nil {
 		msg := fmt.Errorf("find failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	tag := listETag(total, len(films), func(i int) (int, int) { return films[i].Id, films[i].Version })
+	if notModified(w, r, tag) {
+		return
+	}
+
 	renderResponse(w,
-		film,
+		ListFilmsResponse{
+			Items: films,
+			Page:  page,
+		},
 		http.StatusOK)
 }
 
 func (h *FilmHandler) find(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	film, err := h.svc.Find(id)
+	film, err := h.svc.Find(r.Context(), id)
 	if err != nil {
 		msg := fmt.Errorf("find failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	if notModified(w, r, etag(film.Version)) {
+		return
+	}
+
 	renderResponse(w,
 		film,
 		http.StatusOK)
@@ -162,7 +173,7 @@ func (h *FilmHandler) find(w http.ResponseWriter, r *http.Request) {
 func (h *FilmHandler) update(w http.ResponseWriter, r *http.Request) {
 	var req m.UpdateFilm
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		e := internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "json decoder")
+		e := internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
 		msg := fmt.Errorf("invalid request %w", e)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
@@ -172,12 +183,54 @@ func (h *FilmHandler) update(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
 
-	err := h.svc.Update(r.Context(), id, req)
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	updated, err := h.svc.Update(r.Context(), id, req, version)
 	if err != nil {
 		msg := fmt.Errorf("update failed: %w", err)
 		renderErrorResponse(w, msg.Error(), msg)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updated))
+
 	renderResponse(w, &struct{}{}, http.StatusOK)
 }
+
+func (h *FilmHandler) patch(w http.ResponseWriter, r *http.Request) {
+	req, err := readPatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	defer r.Body.Close()
+
+	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	version, err := ifMatch(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	film, err := h.svc.Patch(r.Context(), id, req, version)
+	if err != nil {
+		msg := fmt.Errorf("patch failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	w.Header().Set("ETag", etag(film.Version))
+
+	renderResponse(w,
+		film,
+		http.StatusOK)
+}
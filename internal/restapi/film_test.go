@@ -0,0 +1,674 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/app/service"
+	"filmoteka/internal/restapi/mock_restapi"
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestHandler_FilmCreate(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, f m.CreateFilm)
+
+	testFilm := models.Film{
+		Id:          1,
+		Name:        "Test Name",
+		Description: "Desc1",
+		ReleaseYear: 2002,
+		Rating:      7.5,
+	}
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                m.CreateFilm
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			input: m.CreateFilm{
+				Name:        "Test Name",
+				Description: "Desc1",
+				ReleaseYear: 2002,
+				Rating:      7.5,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.CreateFilm) {
+				r.EXPECT().Create(gomock.Any(), f).Return(testFilm, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: `{"id":1,"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:                 "Wrong Input",
+			inputBody:            "",
+			input:                m.CreateFilm{},
+			mockBehavior:         func(r *mock_restapi.MockFilmService, f m.CreateFilm) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request json decoder: EOF"}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			input: m.CreateFilm{
+				Name:        "Test Name",
+				Description: "Desc1",
+				ReleaseYear: 2002,
+				Rating:      7.5,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.CreateFilm) {
+				r.EXPECT().Create(gomock.Any(), f).Return(models.Film{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/films",
+				bytes.NewBufferString(tt.inputBody))
+
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmFind(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, id string)
+
+	testFilm := models.Film{
+		Id:          1,
+		Name:        "Test Name",
+		Description: "Desc1",
+		ReleaseYear: 2002,
+		Rating:      7.5,
+		Version:     3,
+	}
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		input                string
+		ifNoneMatch          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: ``,
+			input:     "1",
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(testFilm, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:        "Not Modified",
+			inputBody:   ``,
+			input:       "1",
+			ifNoneMatch: `"3"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(testFilm, nil)
+			},
+			expectedStatusCode:   304,
+			expectedETag:         `"3"`,
+			expectedResponseBody: ``,
+		},
+		{
+			name:        "Modified",
+			inputBody:   ``,
+			input:       "1",
+			ifNoneMatch: `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(testFilm, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: ``,
+			input:     "1",
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Find(gomock.Any(), id).Return(models.Film{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			reqUrl := "/films/" + tt.input
+			req := httptest.NewRequest("GET", reqUrl, bytes.NewBufferString(""))
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmFindAll(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService)
+
+	films := []models.Film{
+		{
+			Id:          1,
+			Name:        "Test Name",
+			Description: "Desc1",
+			ReleaseYear: 2002,
+			Rating:      7.5,
+		},
+		{
+			Id:          2,
+			Name:        "Test Name 2",
+			Description: "Desc2",
+			ReleaseYear: 2002,
+			Rating:      7.5,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: ``,
+			mockBehavior: func(r *mock_restapi.MockFilmService) {
+				r.EXPECT().FindAll(gomock.Any(), m.ListFilms{Limit: defaultLimit}).Return(films, 2, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5},{"id":2,"name":"Test Name 2","description":"Desc2","release_year":2002,"rating":7.5}],"total":2,"limit":20,"offset":0}`,
+		},
+		{
+			name:      "Service Error",
+			inputBody: ``,
+			mockBehavior: func(r *mock_restapi.MockFilmService) {
+				r.EXPECT().FindAll(gomock.Any(), m.ListFilms{Limit: defaultLimit}).Return(nil, 0, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/films", bytes.NewBufferString(""))
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc)
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmDelete(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, id string)
+
+	tests := []struct {
+		name                 string
+		input                string
+		inputBody            string
+		ifMatch              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			input:     "1",
+			inputBody: ``,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 2).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "Unconditional",
+			input:     "1",
+			inputBody: ``,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 0).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "Precondition Failed",
+			input:     "1",
+			inputBody: ``,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 2).Return(
+					internal.NewErrorf(internal.ErrorCodePreconditionFailed, "delete film: version 2 is not current"))
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"delete failed: delete film: version 2 is not current"}`,
+		},
+		{
+			name:      "Service Error",
+			input:     "1",
+			inputBody: ``,
+			ifMatch:   `*`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, id string) {
+				r.EXPECT().Delete(gomock.Any(), id, 0).Return(errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			// Create Request
+			w := httptest.NewRecorder()
+			reqUrl := "/films/" + tt.input
+			req := httptest.NewRequest("DELETE", reqUrl, bytes.NewBufferString(""))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmUpdate(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string)
+
+	testFilm := m.UpdateFilm{
+		Name:        "Test Name",
+		Description: "Desc1",
+		ReleaseYear: 2002,
+		Rating:      7.5,
+	}
+
+	tests := []struct {
+		name                 string
+		input                m.UpdateFilm
+		inputBody            string
+		ifMatch              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			input:     testFilm,
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string) {
+				r.EXPECT().Update(gomock.Any(), id, f, 2).Return(3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "Unconditional",
+			input:     testFilm,
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string) {
+				r.EXPECT().Update(gomock.Any(), id, f, 0).Return(3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{}`,
+		},
+		{
+			name:      "Precondition Failed",
+			input:     testFilm,
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			ifMatch:   `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string) {
+				r.EXPECT().Update(gomock.Any(), id, f, 2).Return(0,
+					internal.NewErrorf(internal.ErrorCodePreconditionFailed, "update film: version 2 is not current"))
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"update failed: update film: version 2 is not current"}`,
+		},
+		{
+			name:                 "Weak ETag",
+			input:                testFilm,
+			inputBody:            `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			ifMatch:              `W/"2"`,
+			mockBehavior:         func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string) {},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"invalid request weak etag W/\"2\" does not match"}`,
+		},
+		{
+			name:      "Service Error",
+			input:     testFilm,
+			inputBody: `{"name":"Test Name","description":"Desc1","release_year":2002,"rating":7.5}`,
+			ifMatch:   `*`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, f m.UpdateFilm, id string) {
+				r.EXPECT().Update(gomock.Any(), id, f, 0).Return(0, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/films/1",
+				bytes.NewBufferString(tt.inputBody))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input, "1")
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_FilmPatch(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, p m.Patch, id string)
+
+	testFilm := models.Film{
+		Id:          1,
+		Name:        "Test Name 2",
+		Description: "Desc1",
+		ReleaseYear: 2002,
+		Rating:      7.5,
+		Version:     3,
+	}
+
+	tests := []struct {
+		name                 string
+		contentType          string
+		inputBody            string
+		input                m.Patch
+		ifMatch              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:        "Merge Patch",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"name":"Test Name 2"}`,
+			input:       m.Patch{Type: m.MergePatch, Body: []byte(`{"name":"Test Name 2"}`)},
+			ifMatch:     `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 2).Return(testFilm, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name 2","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:        "JSON Patch",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op":"replace","path":"/name","value":"Test Name 2"}]`,
+			input:       m.Patch{Type: m.JSONPatch, Body: []byte(`[{"op":"replace","path":"/name","value":"Test Name 2"}]`)},
+			ifMatch:     `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 2).Return(testFilm, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name 2","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:        "Unconditional",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"name":"Test Name 2"}`,
+			input:       m.Patch{Type: m.MergePatch, Body: []byte(`{"name":"Test Name 2"}`)},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 0).Return(testFilm, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"3"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name 2","description":"Desc1","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:        "Precondition Failed",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"name":"Test Name 2"}`,
+			input:       m.Patch{Type: m.MergePatch, Body: []byte(`{"name":"Test Name 2"}`)},
+			ifMatch:     `"2"`,
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.Patch, id string) {
+				r.EXPECT().Patch(gomock.Any(), id, p, 2).Return(models.Film{},
+					internal.NewErrorf(internal.ErrorCodePreconditionFailed, "patch film: version 2 is not current"))
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"patch failed: patch film: version 2 is not current"}`,
+		},
+		{
+			name:                 "Unsupported Content Type",
+			contentType:          "text/plain",
+			inputBody:            `name=Test Name 2`,
+			ifMatch:              `"2"`,
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.Patch, id string) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request unsupported content type text/plain"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/films/1",
+				bytes.NewBufferString(tt.inputBody))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input, "1")
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+// racingFilms stores one film which another request changes between the first read and write of
+// a patch.
+type racingFilms struct {
+	service.FilmRepository
+	film  models.Film
+	raced bool
+}
+
+func (r *racingFilms) Find(_ context.Context, id string) (models.Film, error) {
+	return r.film, nil
+}
+
+func (r *racingFilms) Patch(_ context.Context, id string, f m.PatchFilm, version int) error {
+	if !r.raced {
+		r.raced = true
+		r.film.Description = "Desc2"
+		r.film.Version++
+	}
+
+	if version != 0 && version != r.film.Version {
+		return internal.NewErrorf(internal.ErrorCodePreconditionFailed, "patch film: version %d is not current", version)
+	}
+
+	if f.Name != nil {
+		r.film.Name = *f.Name
+	}
+	r.film.Version++
+
+	return nil
+}
+
+func TestHandler_FilmPatchConcurrentWrite(t *testing.T) {
+	tests := []struct {
+		name                 string
+		ifMatch              string
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Unconditional",
+			expectedStatusCode:   200,
+			expectedETag:         `"4"`,
+			expectedResponseBody: `{"id":1,"name":"Test Name 2","description":"Desc2","release_year":2002,"rating":7.5}`,
+		},
+		{
+			name:                 "If-Match",
+			ifMatch:              `"2"`,
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"error":"patch failed: repo patch: patch film: version 2 is not current"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &racingFilms{film: models.Film{
+				Id:          1,
+				Name:        "Test Name",
+				Description: "Desc1",
+				ReleaseYear: 2002,
+				Rating:      7.5,
+				Version:     2,
+			}}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/films/1", bytes.NewBufferString(`{"name":"Test Name 2"}`))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			r := mux.NewRouter()
+			NewFilmHandler(service.NewFilmService(repo, nil), NewCursors([]byte("secret"))).Register(r)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), tt.expectedETag)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
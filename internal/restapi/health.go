@@ -0,0 +1,66 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+)
+
+//go:generate mockgen -source=health.go -destination=mock_restapi/mockhealth.go
+
+// HealthService
+type HealthService interface {
+	Ready(ctx context.Context) models.Health
+}
+
+// HealthHandler
+type HealthHandler struct {
+	svc HealthService
+}
+
+// NewHealthHandler ...
+func NewHealthHandler(svc HealthService) *HealthHandler {
+	return &HealthHandler{
+		svc: svc,
+	}
+}
+
+func (h *HealthHandler) Register(r *mux.Router) {
+	r.HandleFunc("/healthz", h.live).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", h.ready).Methods(http.MethodGet)
+}
+
+//	@Tags Health
+//
+// @Description	check the process is alive
+// @Produce		json
+// @Success		200		{object}	models.Health	"ok"
+// @Router		/healthz [get]
+func (h *HealthHandler) live(w http.ResponseWriter, r *http.Request) {
+	renderResponse(w,
+		models.Health{Status: models.HealthOK},
+		http.StatusOK)
+}
+
+//	@Tags Health
+//
+// @Description	check the service can serve requests: Postgres is critical, Elasticsearch only degrades the service as searches fall back to Postgres
+// @Produce		json
+// @Success		200		{object}	models.Health	"ok or degraded"
+// @Failure		503		{object}	models.Health	"failing or shutting down"
+// @Router		/readyz [get]
+func (h *HealthHandler) ready(w http.ResponseWriter, r *http.Request) {
+	health := h.svc.Ready(r.Context())
+
+	status := http.StatusOK
+	if health.Status == models.HealthFailing || health.Status == models.HealthShuttingDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	renderResponse(w,
+		health,
+		status)
+}
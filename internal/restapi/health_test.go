@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+)
+
+func TestHandler_Health(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockHealthService)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Live",
+			path:                 "/healthz",
+			mockBehavior:         func(r *mock_restapi.MockHealthService) {},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Ready",
+			path: "/readyz",
+			mockBehavior: func(r *mock_restapi.MockHealthService) {
+				r.EXPECT().Ready(gomock.Any()).Return(models.Health{
+					Status: models.HealthOK,
+					Checks: map[string]models.HealthCheck{
+						"postgres": {Status: models.HealthOK, Critical: true, LatencyMs: 1.5},
+					},
+				})
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok","checks":{"postgres":{"status":"ok","critical":true,"latency_ms":1.5}}}`,
+		},
+		{
+			name: "Degraded",
+			path: "/readyz",
+			mockBehavior: func(r *mock_restapi.MockHealthService) {
+				r.EXPECT().Ready(gomock.Any()).Return(models.Health{
+					Status: models.HealthDegraded,
+					Checks: map[string]models.HealthCheck{
+						"elasticsearch": {Status: models.HealthFailing, LatencyMs: 2000, Error: "context deadline exceeded"},
+					},
+				})
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"status":"degraded","checks":{"elasticsearch":{"status":"failing","critical":false,` +
+				`"latency_ms":2000,"error":"context deadline exceeded"}}}`,
+		},
+		{
+			name: "Failing",
+			path: "/readyz",
+			mockBehavior: func(r *mock_restapi.MockHealthService) {
+				r.EXPECT().Ready(gomock.Any()).Return(models.Health{
+					Status: models.HealthFailing,
+					Checks: map[string]models.HealthCheck{
+						"postgres": {Status: models.HealthFailing, Critical: true, LatencyMs: 0.5, Error: "connection refused"},
+					},
+				})
+			},
+			expectedStatusCode: 503,
+			expectedResponseBody: `{"status":"failing","checks":{"postgres":{"status":"failing","critical":true,` +
+				`"latency_ms":0.5,"error":"connection refused"}}}`,
+		},
+		{
+			name: "Shutting Down",
+			path: "/readyz",
+			mockBehavior: func(r *mock_restapi.MockHealthService) {
+				r.EXPECT().Ready(gomock.Any()).Return(models.Health{Status: models.HealthShuttingDown})
+			},
+			expectedStatusCode:   503,
+			expectedResponseBody: `{"status":"shutting_down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockHealthService(c)
+			tt.mockBehavior(svc)
+			NewHealthHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tt.path, bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
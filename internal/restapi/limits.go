@@ -0,0 +1,57 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+)
+
+// LimitBody returns the middleware limiting request bodies to maxBytes, reading past the limit
+// fails and the handlers respond with 413 Request Entity Too Large, see bodyErrorCode.
+func LimitBody(maxBytes int64) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// NOTE: Rejected upfront when declared, without reading anything.
+			if r.ContentLength > maxBytes {
+				msg := internal.NewErrorf(internal.ErrorCodeRequestTooLarge, "request body is larger than %d bytes", maxBytes)
+				renderErrorResponse(w, msg.Error(), msg)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deadline returns the middleware cancelling the work done for a request, SQL statements and
+// search calls included, once timeout elapsed or the client went away; the handlers respond with
+// 504 Gateway Timeout. It applies to the routes of the router it is used on, a subrouter may use
+// a shorter one for its handlers.
+func Deadline(timeout time.Duration) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bodyErrorCode returns the code of an error reading the request body: too large when the body
+// exceeds the limit of LimitBody, invalid otherwise.
+func bodyErrorCode(err error) internal.ErrorCode {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return internal.ErrorCodeRequestTooLarge
+	}
+
+	return internal.ErrorCodeInvalidArgument
+}
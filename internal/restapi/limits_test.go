@@ -0,0 +1,136 @@
+package restapi
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+)
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		name                 string
+		inputBody            string
+		chunked              bool
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Declared Too Large",
+			inputBody:            `{"q":"pirates of the caribbean"}`,
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"error":"request body is larger than 16 bytes"}`,
+		},
+		{
+			name:                 "Read Too Large",
+			inputBody:            `{"q":"pirates of the caribbean"}`,
+			chunked:              true,
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"error":"invalid request json decoder: http: request body too large"}`,
+		},
+		{
+			name:                 "Within Limit",
+			inputBody:            `{"limit":0}`,
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"search failed: invalid"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			r.Use(LimitBody(16))
+			svc := mock_restapi.NewMockFilmService(c)
+			if tt.expectedStatusCode != 413 {
+				svc.EXPECT().Search(gomock.Any(), gomock.Any()).Return(models.FilmSearchResult{},
+					internal.NewErrorf(internal.ErrorCodeInvalidArgument, "invalid"))
+			}
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			var body io.Reader = strings.NewReader(tt.inputBody)
+			if tt.chunked {
+				body = io.MultiReader(body) // NOTE: Hides the length, as chunked requests do.
+			}
+			req := httptest.NewRequest("POST", "/films/search", body)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		name                 string
+		timeout              time.Duration
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Expired",
+			timeout:              time.Millisecond,
+			expectedStatusCode:   504,
+			expectedResponseBody: `{"error":"request timed out"}`,
+		},
+		{
+			name:                 "Within Deadline",
+			timeout:              time.Minute,
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"name":"Test Name","description":"Test Description","release_year":2000,"rating":5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			r.Use(Deadline(tt.timeout))
+			svc := mock_restapi.NewMockFilmService(c)
+			svc.EXPECT().Find(gomock.Any(), "1").DoAndReturn(func(ctx context.Context, id string) (models.Film, error) {
+				// NOTE: A query taking a few milliseconds, cancelled by the deadline.
+				select {
+				case <-ctx.Done():
+					return models.Film{}, internal.WrapErrorf(ctx.Err(), internal.ErrorCodeUnknown, "find film")
+				case <-time.After(20 * time.Millisecond):
+					return models.Film{Id: 1, Name: "Test Name", Description: "Test Description",
+						ReleaseYear: 2000, Rating: 5, Version: 1}, nil
+				}
+			})
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/films/1", nil)
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package mock_restapi
 
 import (
+	context "context"
 	models "filmoteka/internal/app/models"
 	models0 "filmoteka/internal/restapi/models"
 	reflect "reflect"
@@ -36,74 +37,106 @@ func (m *MockActorService) EXPECT() *MockActorServiceMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockActorService) Create(a models0.CreateActor) (models.Actor, error) {
+func (m *MockActorService) Create(ctx context.Context, a models0.CreateActor) (models.Actor, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", a)
+	ret := m.ctrl.Call(m, "Create", ctx, a)
 	ret0, _ := ret[0].(models.Actor)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockActorServiceMockRecorder) Create(a interface{}) *gomock.Call {
+func (mr *MockActorServiceMockRecorder) Create(ctx, a interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockActorService)(nil).Create), a)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockActorService)(nil).Create), ctx, a)
 }
 
 // Delete mocks base method.
-func (m *MockActorService) Delete(id string) error {
+func (m *MockActorService) Delete(ctx context.Context, id string, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockActorServiceMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockActorServiceMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockActorService)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockActorService)(nil).Delete), ctx, id, version)
 }
 
 // Find mocks base method.
-func (m *MockActorService) Find(id string) (models.Actor, error) {
+func (m *MockActorService) Find(ctx context.Context, id string) (models.Actor, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Find", id)
+	ret := m.ctrl.Call(m, "Find", ctx, id)
 	ret0, _ := ret[0].(models.Actor)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Find indicates an expected call of Find.
-func (mr *MockActorServiceMockRecorder) Find(id interface{}) *gomock.Call {
+func (mr *MockActorServiceMockRecorder) Find(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockActorService)(nil).Find), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockActorService)(nil).Find), ctx, id)
 }
 
-// Search mocks base method.
-func (m *MockActorService) Search() ([]models.Actor, error) {
+// FullTextSearch mocks base method.
+func (m *MockActorService) FullTextSearch(ctx context.Context, p models0.SearchActors) (models.ActorSearchResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Search")
-	ret0, _ := ret[0].([]models.Actor)
+	ret := m.ctrl.Call(m, "FullTextSearch", ctx, p)
+	ret0, _ := ret[0].(models.ActorSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FullTextSearch indicates an expected call of FullTextSearch.
+func (mr *MockActorServiceMockRecorder) FullTextSearch(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FullTextSearch", reflect.TypeOf((*MockActorService)(nil).FullTextSearch), ctx, p)
+}
+
+// Patch mocks base method.
+func (m *MockActorService) Patch(ctx context.Context, id string, p models0.Patch, version int) (models.Actor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, p, version)
+	ret0, _ := ret[0].(models.Actor)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// Patch indicates an expected call of Patch.
+func (mr *MockActorServiceMockRecorder) Patch(ctx, id, p, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockActorService)(nil).Patch), ctx, id, p, version)
+}
+
+// Search mocks base method.
+func (m *MockActorService) Search(ctx context.Context, p models0.ListActors) ([]models.Actor, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, p)
+	ret0, _ := ret[0].([]models.Actor)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // Search indicates an expected call of Search.
-func (mr *MockActorServiceMockRecorder) Search() *gomock.Call {
+func (mr *MockActorServiceMockRecorder) Search(ctx, p interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockActorService)(nil).Search))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockActorService)(nil).Search), ctx, p)
 }
 
 // Update mocks base method.
-func (m *MockActorService) Update(id string, a models0.UpdateActor) error {
+func (m *MockActorService) Update(ctx context.Context, id string, a models0.UpdateActor, version int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", id, a)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Update", ctx, id, a, version)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockActorServiceMockRecorder) Update(id, a interface{}) *gomock.Call {
+func (mr *MockActorServiceMockRecorder) Update(ctx, id, a, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockActorService)(nil).Update), id, a)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockActorService)(nil).Update), ctx, id, a, version)
 }
@@ -52,74 +52,106 @@ func (mr *MockFilmServiceMockRecorder) Create(ctx, f interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockFilmService) Delete(ctx context.Context, id string) error {
+func (m *MockFilmService) Delete(ctx context.Context, id string, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockFilmServiceMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+func (mr *MockFilmServiceMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFilmService)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFilmService)(nil).Delete), ctx, id, version)
 }
 
 // Find mocks base method.
-func (m *MockFilmService) Find(id string) (models.Film, error) {
+func (m *MockFilmService) Find(ctx context.Context, id string) (models.Film, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Find", id)
+	ret := m.ctrl.Call(m, "Find", ctx, id)
 	ret0, _ := ret[0].(models.Film)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Find indicates an expected call of Find.
-func (mr *MockFilmServiceMockRecorder) Find(id interface{}) *gomock.Call {
+func (mr *MockFilmServiceMockRecorder) Find(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockFilmService)(nil).Find), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockFilmService)(nil).Find), ctx, id)
 }
 
 // FindAll mocks base method.
-func (m *MockFilmService) FindAll() ([]models.Film, error) {
+func (m *MockFilmService) FindAll(ctx context.Context, p models0.ListFilms) ([]models.Film, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAll")
+	ret := m.ctrl.Call(m, "FindAll", ctx, p)
 	ret0, _ := ret[0].([]models.Film)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockFilmServiceMockRecorder) FindAll(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockFilmService)(nil).FindAll), ctx, p)
+}
+
+// Patch mocks base method.
+func (m *MockFilmService) Patch(ctx context.Context, id string, p models0.Patch, version int) (models.Film, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, p, version)
+	ret0, _ := ret[0].(models.Film)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindAll indicates an expected call of FindAll.
-func (mr *MockFilmServiceMockRecorder) FindAll() *gomock.Call {
+// Patch indicates an expected call of Patch.
+func (mr *MockFilmServiceMockRecorder) Patch(ctx, id, p, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockFilmService)(nil).FindAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockFilmService)(nil).Patch), ctx, id, p, version)
 }
 
 // Search mocks base method.
-func (m *MockFilmService) Search(ctx context.Context, name, description string, releaseYear uint16, rating float32) ([]models.Film, error) {
+func (m *MockFilmService) Search(ctx context.Context, p models0.SearchFilms) (models.FilmSearchResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Search", ctx, name, description, releaseYear, rating)
-	ret0, _ := ret[0].([]models.Film)
+	ret := m.ctrl.Call(m, "Search", ctx, p)
+	ret0, _ := ret[0].(models.FilmSearchResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Search indicates an expected call of Search.
-func (mr *MockFilmServiceMockRecorder) Search(ctx, name, description, releaseYear, rating interface{}) *gomock.Call {
+func (mr *MockFilmServiceMockRecorder) Search(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockFilmService)(nil).Search), ctx, p)
+}
+
+// Similar mocks base method.
+func (m *MockFilmService) Similar(ctx context.Context, id string, p models0.SimilarFilms) ([]models.FilmHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Similar", ctx, id, p)
+	ret0, _ := ret[0].([]models.FilmHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Similar indicates an expected call of Similar.
+func (mr *MockFilmServiceMockRecorder) Similar(ctx, id, p interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockFilmService)(nil).Search), ctx, name, description, releaseYear, rating)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Similar", reflect.TypeOf((*MockFilmService)(nil).Similar), ctx, id, p)
 }
 
 // Update mocks base method.
-func (m *MockFilmService) Update(ctx context.Context, id string, f models0.UpdateFilm) error {
+func (m *MockFilmService) Update(ctx context.Context, id string, f models0.UpdateFilm, version int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, id, f)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Update", ctx, id, f, version)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockFilmServiceMockRecorder) Update(ctx, id, f interface{}) *gomock.Call {
+func (mr *MockFilmServiceMockRecorder) Update(ctx, id, f, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockFilmService)(nil).Update), ctx, id, f)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockFilmService)(nil).Update), ctx, id, f, version)
 }
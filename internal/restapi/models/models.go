@@ -1,9 +1,15 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -84,3 +90,512 @@ func (a *UpdateActor) Validate() error {
 
 	return nil
 }
+
+type CreateCast struct {
+	ActorId int `json:"actor_id" validate:"required,gte=1" example:"1"`
+	// Character played in the film
+	CharacterName string `json:"character_name" validate:"required,min=1,max=150" example:"Jack Sparrow"`
+	// Position in the credits, lower goes first
+	BillingOrder uint16 `json:"billing_order" validate:"lte=32767" maximum:"32767" example:"1"`
+}
+
+func (c *CreateCast) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(c); err != nil {
+		return err
+	}
+	return nil
+}
+
+type UpdateCast struct {
+	// Character played in the film
+	CharacterName string `json:"character_name" validate:"required,min=1,max=150" example:"Jack Sparrow"`
+	// Position in the credits, lower goes first
+	BillingOrder uint16 `json:"billing_order" validate:"lte=32767" maximum:"32767" example:"1"`
+}
+
+func (c *UpdateCast) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(c); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SortField is a field listings are ordered by.
+type SortField struct {
+	Name string `json:"field"`
+	Desc bool   `json:"desc,omitempty"`
+}
+
+// StableSort returns sort truncated after id, or with id appended, so that rows are always
+// totally ordered and paging is stable.
+func StableSort(sort []SortField) []SortField {
+	res := make([]SortField, 0, len(sort)+1)
+	for _, s := range sort {
+		res = append(res, s)
+		if s.Name == "id" {
+			return res
+		}
+	}
+
+	return append(res, SortField{Name: "id"})
+}
+
+// validateAfter checks the keyset of a cursor matches the sort it is used with.
+func validateAfter(sort []SortField, after []interface{}, offset int) error {
+	if after == nil {
+		return nil
+	}
+	if len(after) != len(StableSort(sort)) {
+		return fmt.Errorf("cursor does not match sort")
+	}
+	if offset != 0 {
+		return fmt.Errorf("cursor and offset are mutually exclusive")
+	}
+
+	return nil
+}
+
+// afterTypes returns why the values of after, numbers in the order of sort, don't match the sort
+// fields, wholeFields lists the integer ones; empty when they match.
+func afterTypes(sort []SortField, after []interface{}, wholeFields ...string) string {
+	for i, s := range sort {
+		n, ok := number(after[i])
+		if !ok {
+			return fmt.Sprintf("value %d must be a number, the %s of the last film", i, s.Name)
+		}
+		for _, f := range wholeFields {
+			if s.Name == f && n != math.Trunc(n) {
+				return fmt.Sprintf("value %d must be a whole number, the %s of the last film", i, s.Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// number returns the value of v when it is a number, decoded from JSON or a cursor.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// validateSort checks every field is one of the allowed ones.
+func validateSort(sort []SortField, allowed ...string) error {
+	for _, s := range sort {
+		found := false
+		for _, a := range allowed {
+			if s.Name == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid sort field %q, valid values: %v", s.Name, allowed)
+		}
+	}
+
+	return nil
+}
+
+type ListFilms struct {
+	Limit  int `validate:"gte=1,lte=100"`
+	Offset int `validate:"gte=0"`
+	Sort   []SortField
+	// After holds the sort key values, see StableSort, of the last film of the previous page
+	// when paging with cursors.
+	After      []interface{}
+	YearFrom   *uint16  `validate:"omitempty,gte=1900,lte=2030"`
+	YearTo     *uint16  `validate:"omitempty,gte=1900,lte=2030"`
+	RatingFrom *float32 `validate:"omitempty,gte=0,lte=10"`
+	RatingTo   *float32 `validate:"omitempty,gte=0,lte=10"`
+}
+
+func (l *ListFilms) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(l); err != nil {
+		return err
+	}
+	if err := validateSort(l.Sort, "id", "name", "release_year", "rating"); err != nil {
+		return err
+	}
+	if err := validateAfter(l.Sort, l.After, l.Offset); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type ListActors struct {
+	Limit  int `validate:"gte=1,lte=100"`
+	Offset int `validate:"gte=0"`
+	Sort   []SortField
+	// After holds the sort key values, see StableSort, of the last actor of the previous page
+	// when paging with cursors.
+	After  []interface{}
+	Gender string `validate:"omitempty,oneof=M F"`
+	// Birth date range in 2006-01-02 format
+	BornFrom string
+	BornTo   string
+}
+
+func (l *ListActors) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(l); err != nil {
+		return err
+	}
+	if err := validateSort(l.Sort, "id", "name", "gender", "birth_date"); err != nil {
+		return err
+	}
+	if err := validateAfter(l.Sort, l.After, l.Offset); err != nil {
+		return err
+	}
+	for _, d := range []string{l.BornFrom, l.BornTo} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("valid date format 2006-01-02")
+		}
+	}
+
+	return nil
+}
+
+// SearchFilms is a full text search of films, text criteria are matched against the name and
+// the description of the films.
+type SearchFilms struct {
+	// Query is matched term by term, the more terms match the higher the score
+	Query string `json:"q" validate:"max=200" example:"pirates caribbean"`
+	// Terms every film found contains
+	Must []string `json:"must" validate:"max=10,dive,min=1,max=100"`
+	// Optional terms, films containing them score higher
+	Should []string `json:"should" validate:"max=10,dive,min=1,max=100"`
+	// Phrases every film found contains verbatim
+	Phrases []string `json:"phrases" validate:"max=10,dive,min=1,max=200" example:"black pearl"`
+	// Fuzziness is the number of typos tolerated in each term of q, must and should: 0, 1, 2 or
+	// AUTO, which depends on the length of the term; AUTO by default. Phrases are exact
+	Fuzziness string `json:"fuzziness" validate:"omitempty,oneof=AUTO 0 1 2" example:"AUTO"`
+	// Release year and rating ranges only filter, they don't affect the score
+	YearFrom  *uint16  `json:"year_from" validate:"omitempty,gte=1900,lte=2030"`
+	YearTo    *uint16  `json:"year_to" validate:"omitempty,gte=1900,lte=2030"`
+	RatingMin *float32 `json:"rating_min" validate:"omitempty,gte=0,lte=10"`
+	RatingMax *float32 `json:"rating_max" validate:"omitempty,gte=0,lte=10"`
+	// Facet selections: release decades, e.g. 1990, and rating buckets, see RatingRanges. Values
+	// of a facet are alternatives, facets all apply. They don't affect the score
+	Decades []uint16 `json:"decades" validate:"max=20,dive,gte=1900,lte=2030"`
+	Ratings []string `json:"ratings" validate:"max=10,dive,oneof=0-5 5-6 6-7 7-8 8-9 9-10"`
+	// Weight of the matches in each field, name 2 and description 1 by default
+	Boosts map[string]float32 `json:"boosts" validate:"dive,keys,oneof=name description,endkeys,gt=0,lte=100"`
+	// Sort by score, rating or release_year, by descending score by default
+	Sort  []SortField `json:"sort"`
+	Limit int         `json:"limit" validate:"gte=1,lte=100"`
+	// Page number, starting at 1
+	Page int `json:"page" validate:"gte=1"`
+	// SearchAfter holds the sort values of the last film of the previous page, it replaces page
+	// and goes past the deepest page
+	After []interface{} `json:"search_after"`
+	// Backend is the search backend that served the previous page of a cursor, if any. Scores
+	// and the keyset differ between backends, so the next page must come from the same one.
+	Backend string `json:"-"`
+}
+
+// SearchSort returns the sort of the search, the descending score unless sorted otherwise. The
+// films are additionally sorted by id, see StableSort.
+func (s *SearchFilms) SearchSort() []SortField {
+	if len(s.Sort) == 0 {
+		return []SortField{{Name: "score", Desc: true}}
+	}
+
+	return s.Sort
+}
+
+// MaxSearchWindow is the deepest result a search can page to, index.max_result_window.
+const MaxSearchWindow = 10000
+
+// pastSearchWindow reports whether the page goes past MaxSearchWindow. Divides rather than
+// multiplies, huge pages would overflow.
+func pastSearchWindow(page, limit int) bool {
+	return limit > 0 && page > MaxSearchWindow/limit
+}
+
+// RatingRange is a bucket of the rating facet, From is inclusive and To exclusive except for the
+// highest rating.
+type RatingRange struct {
+	Key      string
+	From, To float32
+}
+
+// RatingRanges are the buckets of the rating facet, keep the oneof check of SearchFilms.Ratings
+// in sync.
+var RatingRanges = []RatingRange{
+	{Key: "0-5", From: 0, To: 5},
+	{Key: "5-6", From: 5, To: 6},
+	{Key: "6-7", From: 6, To: 7},
+	{Key: "7-8", From: 7, To: 8},
+	{Key: "8-9", From: 8, To: 9},
+	{Key: "9-10", From: 9, To: 10},
+}
+
+// Validate returns FieldErrors describing every invalid field.
+func (s *SearchFilms) Validate() error {
+	errs, err := validateFields(s)
+	if err != nil {
+		return err
+	}
+	if err := validateSort(s.Sort, "score", "rating", "release_year"); err != nil {
+		errs.Add("sort", err.Error())
+	}
+	if s.YearFrom != nil && s.YearTo != nil && *s.YearFrom > *s.YearTo {
+		errs.Add("year_to", "must not be before year_from")
+	}
+	if s.RatingMin != nil && s.RatingMax != nil && *s.RatingMin > *s.RatingMax {
+		errs.Add("rating_max", "must not be below rating_min")
+	}
+	for i, d := range s.Decades {
+		if d%10 != 0 {
+			errs.Add(fmt.Sprintf("decades[%d]", i), "must be the first year of a decade, e.g. 1990")
+		}
+	}
+	if pastSearchWindow(s.Page, s.Limit) {
+		errs.Add("page", fmt.Sprintf("must not go past result %d", MaxSearchWindow))
+	}
+	if s.After != nil {
+		if len(s.After) != len(StableSort(s.SearchSort())) {
+			errs.Add("search_after", "must hold a value per sort field and the id")
+		} else if reason := afterTypes(StableSort(s.SearchSort()), s.After, "id", "release_year"); reason != "" {
+			errs.Add("search_after", reason)
+		}
+		if s.Page != 1 {
+			errs.Add("page", "must be 1 with search_after")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// SearchActors is a full text search of actors by name.
+type SearchActors struct {
+	// Query is matched against the name term by term, the more terms match the higher the score
+	Query string `json:"q" validate:"max=100" example:"jonny dep"`
+	// Fuzziness is the number of typos tolerated in each term of q, see SearchFilms
+	Fuzziness string `json:"fuzziness" validate:"omitempty,oneof=AUTO 0 1 2" example:"AUTO"`
+	Gender    string `json:"gender" validate:"omitempty,oneof=M F"`
+	// Birth date range in 2006-01-02 format
+	BornFrom string `json:"born_from" validate:"omitempty,datetime=2006-01-02"`
+	BornTo   string `json:"born_to" validate:"omitempty,datetime=2006-01-02"`
+	Limit    int    `json:"limit" validate:"gte=1,lte=100"`
+	// Page number, starting at 1
+	Page int `json:"page" validate:"gte=1"`
+}
+
+// Validate returns FieldErrors describing every invalid field.
+func (s *SearchActors) Validate() error {
+	errs, err := validateFields(s)
+	if err != nil {
+		return err
+	}
+	// NOTE: Dates in 2006-01-02 format sort like strings.
+	if s.BornFrom != "" && s.BornTo != "" && s.BornFrom > s.BornTo {
+		errs.Add("born_to", "must not be before born_from")
+	}
+	if pastSearchWindow(s.Page, s.Limit) {
+		errs.Add("page", fmt.Sprintf("must not go past result %d", MaxSearchWindow))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// SimilarFilms asks for the films most similar to a film.
+type SimilarFilms struct {
+	// Limit is the number of similar films
+	Limit int `json:"limit" validate:"gte=1,lte=50"`
+}
+
+// Validate returns FieldErrors describing every invalid field.
+func (s *SimilarFilms) Validate() error {
+	errs, err := validateFields(s)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Suggest asks for the films and the actors whose name starts like the text typed so far.
+type Suggest struct {
+	// Query is the text typed so far, every word of it must start a word of the name
+	Query string `json:"q" validate:"required,max=100" example:"pirates of"`
+	// Limit is the number of films and the number of actors
+	Limit int `json:"limit" validate:"gte=1,lte=20"`
+}
+
+// Validate returns FieldErrors describing every invalid field.
+func (s *Suggest) Validate() error {
+	errs, err := validateFields(s)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateFields runs the checks of the validate tags of s, the fields are named after their
+// JSON key.
+func validateFields(s interface{}) (FieldErrors, error) {
+	errs := FieldErrors{}
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonName)
+	if err := validate.Struct(s); err != nil {
+		verrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return nil, err
+		}
+		errs.addValidation(verrs)
+	}
+
+	return errs, nil
+}
+
+// FieldErrors maps the invalid fields, or request parameters, to the reason they are invalid.
+type FieldErrors map[string]string
+
+// Add records the reason field is invalid, the first reason is kept.
+func (f FieldErrors) Add(field, reason string) {
+	if _, ok := f[field]; !ok {
+		f[field] = reason
+	}
+}
+
+func (f FieldErrors) Error() string {
+	fields := make([]string, 0, len(f))
+	for field := range f {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return "invalid " + strings.Join(fields, ", ")
+}
+
+// addValidation records the failed checks of the validator.
+func (f FieldErrors) addValidation(errs validator.ValidationErrors) {
+	for _, e := range errs {
+		// NOTE: Namespace is prefixed with the struct name, e.g. `SearchFilms.must[0]`.
+		_, field, _ := strings.Cut(e.Namespace(), ".")
+		f.Add(field, reason(e))
+	}
+}
+
+// reason describes a failed check of the validator.
+func reason(e validator.FieldError) string {
+	be := "must be "
+	switch e.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		be = "must have a length of "
+	}
+
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "gte", "min":
+		return be + "at least " + e.Param()
+	case "lte", "max":
+		return be + "at most " + e.Param()
+	case "gt":
+		return "must be greater than " + e.Param()
+	case "oneof":
+		return "must be one of: " + e.Param()
+	case "datetime":
+		return "must be a date in " + e.Param() + " format"
+	default:
+		return "is invalid"
+	}
+}
+
+// jsonName names the fields after their JSON key in validation errors.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// PatchType is the media type of a PATCH request body.
+type PatchType string
+
+const (
+	// MergePatch is a JSON Merge Patch, RFC 7386.
+	MergePatch PatchType = "application/merge-patch+json"
+	// JSONPatch is a JSON Patch, RFC 6902.
+	JSONPatch PatchType = "application/json-patch+json"
+)
+
+// Patch is a partial update to apply to the JSON representation of a resource.
+type Patch struct {
+	Type PatchType
+	Body []byte
+}
+
+// Apply returns doc with the patch applied.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	switch p.Type {
+	case MergePatch:
+		return jsonpatch.MergePatch(doc, p.Body)
+	case JSONPatch:
+		patch, err := jsonpatch.DecodePatch(p.Body)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply(doc)
+	default:
+		return nil, fmt.Errorf("unsupported patch type %q", p.Type)
+	}
+}
+
+// PatchFilm holds the changed fields of a Film, nil fields are left untouched.
+type PatchFilm struct {
+	Name        *string
+	Description *string
+	ReleaseYear *uint16
+	Rating      *float32
+}
+
+// PatchActor holds the changed fields of an Actor, nil fields are left untouched.
+type PatchActor struct {
+	Name      *string
+	Gender    *string
+	BirthDate *string
+}
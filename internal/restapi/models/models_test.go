@@ -0,0 +1,151 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"filmoteka/internal/restapi/models"
+)
+
+func TestSearchFilms_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		p    func() *models.SearchFilms
+		errs models.FieldErrors
+	}{
+		{
+			name: "valid",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Query: "pirates", Limit: 20, Page: 1}
+			},
+		},
+		{
+			name: "search after score",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 1, After: []interface{}{1.5, float64(1)}}
+			},
+		},
+		{
+			name: "search after cursor",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{
+					Sort:  []models.SortField{{Name: "release_year"}},
+					Limit: 20,
+					Page:  1,
+					After: []interface{}{int64(2003), json.Number("1")},
+				}
+			},
+		},
+		{
+			name: "search after count",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 1, After: []interface{}{1.5}}
+			},
+			errs: models.FieldErrors{"search_after": "must hold a value per sort field and the id"},
+		},
+		{
+			name: "search after string",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 1, After: []interface{}{"1.5", float64(1)}}
+			},
+			errs: models.FieldErrors{"search_after": "value 0 must be a number, the score of the last film"},
+		},
+		{
+			name: "search after null",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 1, After: []interface{}{1.5, nil}}
+			},
+			errs: models.FieldErrors{"search_after": "value 1 must be a number, the id of the last film"},
+		},
+		{
+			name: "search after fractional id",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 1, After: []interface{}{1.5, 1.5}}
+			},
+			errs: models.FieldErrors{"search_after": "value 1 must be a whole number, the id of the last film"},
+		},
+		{
+			name: "search after fractional release year",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{
+					Sort:  []models.SortField{{Name: "rating", Desc: true}, {Name: "release_year"}},
+					Limit: 20,
+					Page:  1,
+					After: []interface{}{8.5, 2003.5, float64(1)},
+				}
+			},
+			errs: models.FieldErrors{"search_after": "value 1 must be a whole number, the release_year of the last film"},
+		},
+		{
+			name: "search after with page",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 20, Page: 2, After: []interface{}{1.5, float64(1)}}
+			},
+			errs: models.FieldErrors{"page": "must be 1 with search_after"},
+		},
+		{
+			name: "last page",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 4, Page: models.MaxSearchWindow / 4}
+			},
+		},
+		{
+			name: "past the window",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 3, Page: models.MaxSearchWindow/3 + 1}
+			},
+			errs: models.FieldErrors{"page": "must not go past result 10000"},
+		},
+		{
+			name: "overflowing page",
+			p: func() *models.SearchFilms {
+				return &models.SearchFilms{Limit: 4, Page: 1 << 62}
+			},
+			errs: models.FieldErrors{"page": "must not go past result 10000"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.p().Validate()
+			if tc.errs == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, tc.errs, err)
+		})
+	}
+}
+
+func TestSearchActors_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		p    *models.SearchActors
+		errs models.FieldErrors
+	}{
+		{
+			name: "valid",
+			p:    &models.SearchActors{Query: "depp", Limit: 20, Page: 1},
+		},
+		{
+			name: "overflowing page",
+			p:    &models.SearchActors{Limit: 4, Page: 1 << 62},
+			errs: models.FieldErrors{"page": "must not go past result 10000"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.p.Validate()
+			if tc.errs == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, tc.errs, err)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package restapi
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"filmoteka/internal"
+	m "filmoteka/internal/restapi/models"
+)
+
+const (
+	defaultLimit = 20
+)
+
+// parseListFilms reads the paging, sorting and filtering query parameters of GET /films.
+func (c *Cursors) parseListFilms(q url.Values) (m.ListFilms, error) {
+	var (
+		p   m.ListFilms
+		err error
+	)
+
+	if p.Limit, p.Offset, err = parsePage(q); err != nil {
+		return m.ListFilms{}, err
+	}
+	if p.Sort, p.After, err = c.parseCursor(q, "rating"); err != nil {
+		return m.ListFilms{}, err
+	}
+	if p.YearFrom, err = parseUint16(q, "year_from"); err != nil {
+		return m.ListFilms{}, err
+	}
+	if p.YearTo, err = parseUint16(q, "year_to"); err != nil {
+		return m.ListFilms{}, err
+	}
+	if p.RatingFrom, err = parseFloat32(q, "rating_from"); err != nil {
+		return m.ListFilms{}, err
+	}
+	if p.RatingTo, err = parseFloat32(q, "rating_to"); err != nil {
+		return m.ListFilms{}, err
+	}
+
+	return p, nil
+}
+
+// parseListActors reads the paging, sorting and filtering query parameters of GET /actors.
+func (c *Cursors) parseListActors(q url.Values) (m.ListActors, error) {
+	p := m.ListActors{
+		Gender:   q.Get("gender"),
+		BornFrom: q.Get("born_from"),
+		BornTo:   q.Get("born_to"),
+	}
+
+	var err error
+	if p.Limit, p.Offset, err = parsePage(q); err != nil {
+		return m.ListActors{}, err
+	}
+	if p.Sort, p.After, err = c.parseCursor(q); err != nil {
+		return m.ListActors{}, err
+	}
+
+	return p, nil
+}
+
+// parseCursor reads the sort and cursor query parameters. When a cursor is given the sort it was
+// created for is used, a different explicit sort is rejected.
+func (c *Cursors) parseCursor(q url.Values, floatKeys ...string) ([]m.SortField, []interface{}, error) {
+	sort := parseSort(q.Get("sort"))
+
+	token := q.Get("cursor")
+	if token == "" {
+		return sort, nil, nil
+	}
+
+	cur, err := c.decode(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q.Has("sort") && formatSort(sort) != cur.Sort {
+		return nil, nil, internal.NewErrorf(internal.ErrorCodeInvalidArgument, "sort does not match cursor")
+	}
+
+	sort = parseSort(cur.Sort)
+
+	after, err := keyValues(sort, cur.Values, floatKeys...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sort, after, nil
+}
+
+// parseSort reads a comma separated list of fields, a leading `-` means descending order,
+// e.g. `rating,-release_year`.
+func parseSort(raw string) []m.SortField {
+	if raw == "" {
+		return nil
+	}
+
+	fields := strings.Split(raw, ",")
+	sort := make([]m.SortField, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if strings.HasPrefix(f, "-") {
+			sort = append(sort, m.SortField{Name: f[1:], Desc: true})
+		} else {
+			sort = append(sort, m.SortField{Name: strings.TrimPrefix(f, "+")})
+		}
+	}
+
+	return sort
+}
+
+func parsePage(q url.Values) (int, int, error) {
+	limit, offset := defaultLimit, 0
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid limit")
+		}
+		limit = n
+	}
+
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid offset")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
+func parseInt(q url.Values, key string, def int) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid %s", key)
+	}
+
+	return n, nil
+}
+
+func parseUint16(q url.Values, key string) (*uint16, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 16)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid %s", key)
+	}
+
+	res := uint16(n)
+
+	return &res, nil
+}
+
+func parseFloat32(q url.Values, key string) (*float32, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseFloat(v, 32)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "invalid %s", key)
+	}
+
+	res := float32(n)
+
+	return &res, nil
+}
+
+// Page holds the paging details of a listing response.
+type Page struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listing describes a page of a listing as requested and as returned.
+type listing struct {
+	sort   []m.SortField
+	limit  int
+	offset int
+	after  []interface{}
+	count  int
+	total  int
+	// last returns the sort key values of the last item of the page.
+	last func() []interface{}
+}
+
+// newPage returns the links to the next and previous pages of a listing, paging is done with
+// offsets unless the request used a cursor. Cursor paging only goes forward.
+func (c *Cursors) newPage(r *http.Request, l listing) (Page, error) {
+	page := Page{
+		Total:  l.total,
+		Limit:  l.limit,
+		Offset: l.offset,
+	}
+
+	link := func(set func(q url.Values)) string {
+		q := r.URL.Query()
+		q.Set("limit", strconv.Itoa(l.limit))
+		set(q)
+
+		return r.URL.Path + "?" + q.Encode()
+	}
+
+	more := l.offset+l.count < l.total
+	if l.after != nil {
+		more = l.count == l.limit
+	}
+
+	if more && l.count > 0 {
+		token, err := c.encode(cursor{Sort: formatSort(l.sort), Values: l.last()})
+		if err != nil {
+			return Page{}, err
+		}
+
+		page.NextCursor = token
+	}
+
+	if l.after != nil {
+		if page.NextCursor != "" {
+			page.Next = link(func(q url.Values) {
+				q.Del("sort")
+				q.Set("cursor", page.NextCursor)
+			})
+		}
+
+		return page, nil
+	}
+
+	if more {
+		page.Next = link(func(q url.Values) { q.Set("offset", strconv.Itoa(l.offset+l.limit)) })
+	}
+
+	if l.offset > 0 {
+		page.Prev = link(func(q url.Values) { q.Set("offset", strconv.Itoa(max(l.offset-l.limit, 0))) })
+	}
+
+	return page, nil
+}
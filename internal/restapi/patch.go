@@ -0,0 +1,40 @@
+package restapi
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"filmoteka/internal"
+	m "filmoteka/internal/restapi/models"
+)
+
+// readPatch reads the body of a PATCH request, its format is chosen by Content-Type: JSON Patch
+// for `application/json-patch+json`, JSON Merge Patch otherwise.
+func readPatch(r *http.Request) (m.Patch, error) {
+	p := m.Patch{Type: m.MergePatch}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return m.Patch{}, internal.WrapErrorf(err, internal.ErrorCodeInvalidArgument, "content type")
+		}
+
+		switch mt {
+		case string(m.JSONPatch):
+			p.Type = m.JSONPatch
+		case string(m.MergePatch), "application/json":
+		default:
+			return m.Patch{}, internal.NewErrorf(internal.ErrorCodeInvalidArgument, "unsupported content type %s", mt)
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return m.Patch{}, internal.WrapErrorf(err, bodyErrorCode(err), "read body")
+	}
+
+	p.Body = body
+
+	return p, nil
+}
@@ -0,0 +1,54 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+)
+
+//go:generate mockgen -source=relay.go -destination=mock_restapi/mockrelay.go
+
+// SearchRelayService
+type SearchRelayService interface {
+	Stats(ctx context.Context) (models.RelayStats, error)
+}
+
+// SearchRelayHandler
+type SearchRelayHandler struct {
+	svc SearchRelayService
+}
+
+// NewSearchRelayHandler ...
+func NewSearchRelayHandler(svc SearchRelayService) *SearchRelayHandler {
+	return &SearchRelayHandler{
+		svc: svc,
+	}
+}
+
+func (h *SearchRelayHandler) Register(r *mux.Router) {
+	r.HandleFunc("/search/outbox", h.stats).Methods(http.MethodGet)
+}
+
+//	@Tags Search
+//
+// @Description	get the lag and the failures of the delivery of changes to the search index
+// @Produce		json
+// @Success		200		{object}	models.RelayStats	"ok"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/search/outbox [get]
+func (h *SearchRelayHandler) stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.svc.Stats(r.Context())
+	if err != nil {
+		msg := fmt.Errorf("stats failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		stats,
+		http.StatusOK)
+}
@@ -0,0 +1,79 @@
+package restapi
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+)
+
+func TestHandler_SearchRelayStats(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockSearchRelayService)
+
+	stats := models.RelayStats{
+		OutboxStats: models.OutboxStats{
+			Pending:    3,
+			Retrying:   1,
+			LagSeconds: 12.5,
+			Dead:       1,
+		},
+		Delivered: 40,
+		Failed:    2,
+	}
+
+	tests := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok",
+			mockBehavior: func(r *mock_restapi.MockSearchRelayService) {
+				r.EXPECT().Stats(gomock.Any()).Return(stats, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"pending":3,"retrying":1,"lag_seconds":12.5,"dead":1,"delivered":40,"failed":2}`,
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(r *mock_restapi.MockSearchRelayService) {
+				r.EXPECT().Stats(gomock.Any()).Return(models.RelayStats{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockSearchRelayService(c)
+			tt.mockBehavior(svc)
+			NewSearchRelayHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/search/outbox", bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
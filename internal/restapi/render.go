@@ -1,23 +1,33 @@
 package restapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"filmoteka/internal"
+	m "filmoteka/internal/restapi/models"
 )
 
 // ErrorResponse represents a response containing an error message.
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Fields holds the reason each invalid field or parameter is invalid, if known.
+	Fields m.FieldErrors `json:"fields,omitempty"`
 }
 
 func renderErrorResponse(w http.ResponseWriter, msg string, err error) {
 	resp := ErrorResponse{Error: msg}
 	status := http.StatusInternalServerError
 
+	// NOTE: The deadline of the request, see Deadline, or of a call made for it expired.
+	if errors.Is(err, context.DeadlineExceeded) {
+		renderResponse(w, ErrorResponse{Error: "request timed out"}, http.StatusGatewayTimeout)
+		return
+	}
+
 	var ierr *internal.Error
 	if !errors.As(err, &ierr) {
 		resp.Error = "internal error"
@@ -27,6 +37,19 @@ func renderErrorResponse(w http.ResponseWriter, msg string, err error) {
 			status = http.StatusNotFound
 		case internal.ErrorCodeInvalidArgument:
 			status = http.StatusBadRequest
+
+			var fields m.FieldErrors
+			if errors.As(err, &fields) {
+				resp.Fields = fields
+			}
+		case internal.ErrorCodeUniqueConstraints:
+			status = http.StatusConflict
+		case internal.ErrorCodePreconditionFailed:
+			status = http.StatusPreconditionFailed
+		case internal.ErrorCodeUnavailable:
+			status = http.StatusServiceUnavailable
+		case internal.ErrorCodeRequestTooLarge:
+			status = http.StatusRequestEntityTooLarge
 		}
 	}
 
@@ -0,0 +1,309 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+//	@Tags Films
+//
+// @Description	search films, text criteria are matched against the name and the description
+// @Produce		json
+// @Param		q			query		string		false	"Text to search for, the more terms match the higher the score"
+// @Param		must		query		[]string	false	"Terms every film found contains"	collectionFormat(multi)
+// @Param		should		query		[]string	false	"Optional terms, films containing them score higher"	collectionFormat(multi)
+// @Param		phrase		query		[]string	false	"Phrases every film found contains verbatim"	collectionFormat(multi)
+// @Param		fuzziness	query		string		false	"Typos tolerated in each term of q, must and should: 0, 1, 2 or AUTO"	default(AUTO)
+// @Param		year_from	query		int			false	"Earliest release year, doesn't affect the score"
+// @Param		year_to		query		int			false	"Latest release year, doesn't affect the score"
+// @Param		rating_min	query		number		false	"Minimum rating, doesn't affect the score"
+// @Param		rating_max	query		number		false	"Maximum rating, doesn't affect the score"
+// @Param		decade		query		[]int		false	"Selected release decades, e.g. 1990"	collectionFormat(multi)
+// @Param		rating		query		[]string	false	"Selected rating buckets: 0-5, 5-6, 6-7, 7-8, 8-9 or 9-10"	collectionFormat(multi)
+// @Param		boost		query		[]string	false	"Field weight, e.g. name:3"	collectionFormat(multi)
+// @Param		sort		query		string		false	"Comma separated score, rating or release_year, `-` for descending, e.g. -rating,-score"
+// @Param		page		query		int			false	"Page number"	default(1)
+// @Param		limit		query		int			false	"Page size, 1 to 100"	default(20)
+// @Param		cursor		query		string		false	"next_cursor of the previous page, replaces page"
+// @Success		200		{object}	SearchFilmsResponse	"ok"
+// @Failure		400		{object}	ErrorResponse	"Bad request, fields holds the invalid parameters"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/search [get]
+func (h *FilmHandler) search(w http.ResponseWriter, r *http.Request) {
+	req, err := h.cursors.parseSearchFilms(r.URL.Query())
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	h.renderSearch(w, r, req)
+}
+
+//	@Tags Films
+//
+// @Description	search films with a JSON query, for queries too complex for query parameters
+// @Accept		json
+// @Produce		json
+// @Param		json	body		m.SearchFilms	true	"search query"
+// @Success		200		{object}	SearchFilmsResponse	"ok"
+// @Failure		400		{object}	ErrorResponse	"Bad request, fields holds the invalid fields"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/search [post]
+func (h *FilmHandler) searchJSON(w http.ResponseWriter, r *http.Request) {
+	req, err := readSearchFilms(r)
+	if err != nil {
+		msg := fmt.Errorf("invalid request %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	defer r.Body.Close()
+
+	h.renderSearch(w, r, req)
+}
+
+//	@Tags Films
+//
+// @Description	get the films most similar to a film by name and description, most similar first, the film itself excluded
+// @Produce		json
+// @Param		id		path		int		true	"Film ID"
+// @Param		limit	query		int		false	"Number of films, 1 to 50"	default(10)
+// @Success		200		{object}	SimilarFilmsResponse	"ok"
+// @Failure		400		{object}	ErrorResponse	"Bad request, fields holds the invalid parameters"
+// @Failure		404		{object}	internal.Error	"Film not found"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/films/{id}/similar [get]
+func (h *FilmHandler) similar(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"] // NOTE: Safe to ignore error, because it's always defined.
+
+	var (
+		p   m.SimilarFilms
+		err error
+	)
+	if p.Limit, err = parseInt(r.URL.Query(), "limit", defaultSimilarLimit); err != nil {
+		msg := fmt.Errorf("invalid request %w",
+			internal.WrapErrorf(m.FieldErrors{"limit": "must be a whole number"}, internal.ErrorCodeInvalidArgument, "query"))
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	hits, err := h.svc.Similar(r.Context(), id, p)
+	if err != nil {
+		msg := fmt.Errorf("similar failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		SimilarFilmsResponse{
+			Items: hits,
+		},
+		http.StatusOK)
+}
+
+const defaultSimilarLimit = 10
+
+// SimilarFilmsResponse holds the films most similar to a film, most similar first.
+type SimilarFilmsResponse struct {
+	Items []models.FilmHit `json:"items"`
+}
+
+// SearchFilmsResponse is a page of the films found by a search. Pages past the first 10000
+// films are reached with next_cursor, or search_after for JSON searches. Facets count the films
+// found by decade and by rating bucket, their keys select them with the decade and rating
+// parameters. Backend names the search backend which served the search: elasticsearch, or
+// postgresql while Elasticsearch is unavailable, which scores differently and has no highlights.
+// A next_cursor is rejected once another backend serves the search.
+type SearchFilmsResponse struct {
+	Items       []models.FilmHit                `json:"items"`
+	Facets      map[string][]models.FacetBucket `json:"facets,omitempty"`
+	Total       int                             `json:"total"`
+	Page        int                             `json:"page"`
+	Limit       int                             `json:"limit"`
+	Next        string                          `json:"next,omitempty"`
+	NextCursor  string                          `json:"next_cursor,omitempty"`
+	SearchAfter []interface{}                   `json:"search_after,omitempty"`
+	Backend     string                          `json:"backend,omitempty"`
+}
+
+func (h *FilmHandler) renderSearch(w http.ResponseWriter, r *http.Request, req m.SearchFilms) {
+	res, err := h.svc.Search(r.Context(), req)
+	if err != nil {
+		msg := fmt.Errorf("search failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	// NOTE: The backend failed over, or recovered, since the cursor was created.
+	if req.Backend != "" && req.Backend != res.Backend {
+		e := internal.WrapErrorf(m.FieldErrors{"cursor": "was created by the " + req.Backend + " search backend, search again"},
+			internal.ErrorCodeInvalidArgument, "query")
+		msg := fmt.Errorf("invalid request %w", e)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	resp := SearchFilmsResponse{
+		Items:   res.Hits,
+		Facets:  res.Facets,
+		Total:   res.Total,
+		Page:    req.Page,
+		Limit:   req.Limit,
+		Backend: res.Backend,
+	}
+
+	more := (req.Page-1)*req.Limit+len(res.Hits) < res.Total
+	if req.After != nil {
+		more = len(res.Hits) == req.Limit
+	}
+
+	if more && len(res.Hits) > 0 {
+		token, err := h.cursors.encode(cursor{Sort: formatSort(req.SearchSort()), Values: res.After, Backend: res.Backend})
+		if err != nil {
+			msg := fmt.Errorf("search failed: %w", err)
+			renderErrorResponse(w, msg.Error(), msg)
+			return
+		}
+
+		resp.NextCursor = token
+		resp.SearchAfter = res.After
+
+		if r.Method == http.MethodGet {
+			q := r.URL.Query()
+			q.Del("sort")
+			q.Del("page")
+			q.Set("cursor", token)
+			resp.Next = r.URL.Path + "?" + q.Encode()
+		}
+	}
+
+	renderResponse(w,
+		resp,
+		http.StatusOK)
+}
+
+// parseSearchFilms reads the query parameters of GET /films/search, every unparsable parameter
+// is reported.
+func (c *Cursors) parseSearchFilms(q url.Values) (m.SearchFilms, error) {
+	p := m.SearchFilms{
+		Query:     q.Get("q"),
+		Must:      q["must"],
+		Should:    q["should"],
+		Phrases:   q["phrase"],
+		Fuzziness: q.Get("fuzziness"),
+		Ratings:   q["rating"],
+		Limit:     defaultLimit,
+		Page:      1,
+	}
+
+	errs := m.FieldErrors{}
+
+	var err error
+	if p.Sort, p.After, err = c.parseCursor(q, "score", "rating"); err != nil {
+		errs.Add("cursor", "must be the next_cursor of a search with the same sort")
+	} else if token := q.Get("cursor"); token != "" {
+		cur, _ := c.decode(token) // NOTE: Safe to ignore error, parseCursor decoded it already.
+		p.Backend = cur.Backend
+	}
+	if p.YearFrom, err = parseUint16(q, "year_from"); err != nil {
+		errs.Add("year_from", "must be a year")
+	}
+	if p.YearTo, err = parseUint16(q, "year_to"); err != nil {
+		errs.Add("year_to", "must be a year")
+	}
+	if p.RatingMin, err = parseFloat32(q, "rating_min"); err != nil {
+		errs.Add("rating_min", "must be a number")
+	}
+	if p.RatingMax, err = parseFloat32(q, "rating_max"); err != nil {
+		errs.Add("rating_max", "must be a number")
+	}
+	if p.Limit, err = parseInt(q, "limit", p.Limit); err != nil {
+		errs.Add("limit", "must be a whole number")
+	}
+	if p.Page, err = parseInt(q, "page", p.Page); err != nil {
+		errs.Add("page", "must be a whole number")
+	}
+
+	for _, d := range q["decade"] {
+		n, err := strconv.ParseUint(d, 10, 16)
+		if err != nil {
+			errs.Add("decade", "must be a year")
+			continue
+		}
+		p.Decades = append(p.Decades, uint16(n))
+	}
+
+	for _, b := range q["boost"] {
+		field, weight, ok := strings.Cut(b, ":")
+		n, err := strconv.ParseFloat(weight, 32)
+		if !ok || err != nil {
+			errs.Add("boost", "must be field:weight, e.g. name:3")
+			continue
+		}
+		if p.Boosts == nil {
+			p.Boosts = map[string]float32{}
+		}
+		p.Boosts[field] = float32(n)
+	}
+
+	if len(errs) > 0 {
+		return m.SearchFilms{}, internal.WrapErrorf(errs, internal.ErrorCodeInvalidArgument, "query")
+	}
+
+	return p, nil
+}
+
+// readSearchFilms reads the JSON body of POST /films/search, unknown fields are rejected.
+func readSearchFilms(r *http.Request) (m.SearchFilms, error) {
+	p := m.SearchFilms{
+		Limit: defaultLimit,
+		Page:  1,
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&p); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			err = m.FieldErrors{typeErr.Field: "must be " + jsonType(typeErr.Type)}
+		} else if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			err = m.FieldErrors{strings.Trim(field, `"`): "is not supported"}
+		}
+
+		return m.SearchFilms{}, internal.WrapErrorf(err, bodyErrorCode(err), "json decoder")
+	}
+
+	return p, nil
+}
+
+// jsonType describes the JSON values t is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
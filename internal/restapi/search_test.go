@@ -0,0 +1,415 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestHandler_FilmSearch(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, p m.SearchFilms)
+
+	result := models.FilmSearchResult{
+		Total: 11,
+		Hits: []models.FilmHit{
+			{
+				Film: models.Film{
+					Id:          1,
+					Name:        "Film 1",
+					Description: "Desc 1",
+					ReleaseYear: 2003,
+					Rating:      8,
+				},
+				Score: 1.5,
+			},
+		},
+		After: []interface{}{json.Number("8"), json.Number("1")},
+	}
+
+	highlighted := result
+	highlighted.Hits = []models.FilmHit{result.Hits[0]}
+	highlighted.Hits[0].Highlights = map[string][]string{"name": {"<em>Film</em> 1"}}
+
+	faceted := result
+	faceted.Facets = map[string][]models.FacetBucket{
+		"decade": {{Key: "2000", Count: 11, Selected: true}},
+		"rating": {{Key: "7-8", Count: 4}, {Key: "8-9", Count: 7, Selected: true}},
+	}
+
+	fallback := result
+	fallback.Total = 1
+	fallback.Backend = models.SearchBackendPostgres
+
+	cursors := NewCursors([]byte("secret"))
+	token, _ := cursors.encode(cursor{Sort: "-rating", Values: []interface{}{json.Number("8"), json.Number("1")}})
+	scoreToken, _ := cursors.encode(cursor{Sort: "-score", Values: []interface{}{json.Number("8"), json.Number("1")}})
+	esToken, _ := cursors.encode(cursor{Sort: "-rating", Values: []interface{}{json.Number("8"), json.Number("1")},
+		Backend: models.SearchBackendElasticsearch})
+
+	primary := models.FilmSearchResult{Total: 11, Backend: models.SearchBackendElasticsearch}
+
+	yearFrom := uint16(2000)
+	ratingMin := float32(7.5)
+
+	tests := []struct {
+		name                 string
+		method               string
+		query                string
+		inputBody            string
+		input                m.SearchFilms
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Query",
+			method: "GET",
+			query:  "?q=pirates&must=sea&phrase=black+pearl&fuzziness=1&year_from=2000&rating_min=7.5&boost=name:3&sort=-rating&page=2&limit=10",
+			input: m.SearchFilms{
+				Query:     "pirates",
+				Must:      []string{"sea"},
+				Phrases:   []string{"black pearl"},
+				Fuzziness: "1",
+				YearFrom:  &yearFrom,
+				RatingMin: &ratingMin,
+				Boosts:    map[string]float32{"name": 3},
+				Sort:      []m.SortField{{Name: "rating", Desc: true}},
+				Limit:     10,
+				Page:      2,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(highlighted, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5,` +
+				`"highlights":{"name":["\u003cem\u003eFilm\u003c/em\u003e 1"]}}],"total":11,"page":2,"limit":10}`,
+		},
+		{
+			name:   "Facets",
+			method: "GET",
+			query:  "?q=pirates&decade=2000&rating=8-9",
+			input: m.SearchFilms{
+				Query:   "pirates",
+				Decades: []uint16{2000},
+				Ratings: []string{"8-9"},
+				Limit:   20,
+				Page:    1,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(faceted, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5}],` +
+				`"facets":{"decade":[{"key":"2000","count":11,"selected":true}],"rating":[{"key":"7-8","count":4,"selected":false},{"key":"8-9","count":7,"selected":true}]},` +
+				`"total":11,"page":1,"limit":20,"next":"/films/search?cursor=` + scoreToken + `\u0026decade=2000\u0026q=pirates\u0026rating=8-9",` +
+				`"next_cursor":"` + scoreToken + `","search_after":[8,1]}`,
+		},
+		{
+			name:   "More",
+			method: "GET",
+			query:  "?sort=-rating&limit=1",
+			input: m.SearchFilms{
+				Sort:  []m.SortField{{Name: "rating", Desc: true}},
+				Limit: 1,
+				Page:  1,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(result, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5}],"total":11,"page":1,"limit":1,` +
+				`"next":"/films/search?cursor=` + token + `\u0026limit=1","next_cursor":"` + token + `","search_after":[8,1]}`,
+		},
+		{
+			name:   "Fallback Backend",
+			method: "GET",
+			query:  "?q=pirates",
+			input: m.SearchFilms{
+				Query: "pirates",
+				Limit: 20,
+				Page:  1,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(fallback, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5}],` +
+				`"total":1,"page":1,"limit":20,"backend":"postgresql"}`,
+		},
+		{
+			name:   "Cursor",
+			method: "GET",
+			query:  "?cursor=" + token + "&limit=1",
+			input: m.SearchFilms{
+				Sort:  []m.SortField{{Name: "rating", Desc: true}},
+				Limit: 1,
+				Page:  1,
+				After: []interface{}{float32(8), int64(1)},
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(models.FilmSearchResult{Total: 11}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":null,"total":11,"page":1,"limit":1}`,
+		},
+		{
+			name:   "Cursor Same Backend",
+			method: "GET",
+			query:  "?cursor=" + esToken + "&limit=1",
+			input: m.SearchFilms{
+				Sort:    []m.SortField{{Name: "rating", Desc: true}},
+				Limit:   1,
+				Page:    1,
+				After:   []interface{}{float32(8), int64(1)},
+				Backend: models.SearchBackendElasticsearch,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(primary, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":null,"total":11,"page":1,"limit":1,"backend":"elasticsearch"}`,
+		},
+		{
+			name:   "Cursor Other Backend",
+			method: "GET",
+			query:  "?cursor=" + esToken + "&limit=1",
+			input: m.SearchFilms{
+				Sort:    []m.SortField{{Name: "rating", Desc: true}},
+				Limit:   1,
+				Page:    1,
+				After:   []interface{}{float32(8), int64(1)},
+				Backend: models.SearchBackendElasticsearch,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(fallback, nil)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid cursor","fields":{"cursor":"was created by the elasticsearch search backend, search again"}}`,
+		},
+		{
+			name:                 "Cursor Sort Mismatch",
+			method:               "GET",
+			query:                "?cursor=" + token + "&sort=-release_year",
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.SearchFilms) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid cursor","fields":{"cursor":"must be the next_cursor of a search with the same sort"}}`,
+		},
+		{
+			name:                 "Invalid Parameters",
+			method:               "GET",
+			query:                "?year_from=soon&limit=ten&boost=name&decade=nineties",
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.SearchFilms) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid boost, decade, limit, year_from","fields":{"boost":"must be field:weight, e.g. name:3","decade":"must be a year","limit":"must be a whole number","year_from":"must be a year"}}`,
+		},
+		{
+			name:   "Invalid Values",
+			method: "GET",
+			query:  "?limit=500",
+			input:  m.SearchFilms{Limit: 500, Page: 1},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(models.FilmSearchResult{},
+					internal.WrapErrorf(m.FieldErrors{"limit": "must be at most 100"},
+						internal.ErrorCodeInvalidArgument, "validate search"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"search failed: validate search: invalid limit","fields":{"limit":"must be at most 100"}}`,
+		},
+		{
+			name:      "JSON",
+			method:    "POST",
+			inputBody: `{"q":"pirates","should":["sea"],"rating_max":9,"sort":[{"field":"score","desc":true}]}`,
+			input: m.SearchFilms{
+				Query:     "pirates",
+				Should:    []string{"sea"},
+				RatingMax: func() *float32 { v := float32(9); return &v }(),
+				Sort:      []m.SortField{{Name: "score", Desc: true}},
+				Limit:     20,
+				Page:      1,
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(result, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5}],"total":11,"page":1,"limit":20,` +
+				`"next_cursor":"` + scoreToken + `","search_after":[8,1]}`,
+		},
+		{
+			name:      "JSON Search After",
+			method:    "POST",
+			inputBody: `{"q":"pirates","limit":1,"search_after":[8,1]}`,
+			input: m.SearchFilms{
+				Query: "pirates",
+				Limit: 1,
+				Page:  1,
+				After: []interface{}{float64(8), float64(1)},
+			},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(result, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"items":[{"id":1,"name":"Film 1","description":"Desc 1","release_year":2003,"rating":8,"score":1.5}],"total":11,"page":1,"limit":1,` +
+				`"next_cursor":"` + scoreToken + `","search_after":[8,1]}`,
+		},
+		{
+			name:                 "JSON Wrong Type",
+			method:               "POST",
+			inputBody:            `{"year_from":"2000"}`,
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.SearchFilms) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request json decoder: invalid year_from","fields":{"year_from":"must be a number"}}`,
+		},
+		{
+			name:                 "JSON Unknown Field",
+			method:               "POST",
+			inputBody:            `{"title":"pirates"}`,
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.SearchFilms) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request json decoder: invalid title","fields":{"title":"is not supported"}}`,
+		},
+		{
+			name:   "Service Error",
+			method: "GET",
+			query:  "?q=pirates",
+			input:  m.SearchFilms{Query: "pirates", Limit: 20, Page: 1},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SearchFilms) {
+				r.EXPECT().Search(gomock.Any(), p).Return(models.FilmSearchResult{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewFilmHandler(svc, cursors).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/films/search"+tt.query,
+				bytes.NewBufferString(tt.inputBody))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_SimilarFilms(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockFilmService, p m.SimilarFilms)
+
+	hits := []models.FilmHit{
+		{
+			Film: models.Film{
+				Id:          2,
+				Name:        "Film 2",
+				Description: "Desc 2",
+				ReleaseYear: 2006,
+				Rating:      7,
+			},
+			Score: 3.25,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		query                string
+		input                m.SimilarFilms
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "?limit=3",
+			input: m.SimilarFilms{Limit: 3},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SimilarFilms) {
+				r.EXPECT().Similar(gomock.Any(), "1", p).Return(hits, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[{"id":2,"name":"Film 2","description":"Desc 2","release_year":2006,"rating":7,"score":3.25}]}`,
+		},
+		{
+			name:  "Default Limit",
+			input: m.SimilarFilms{Limit: 10},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SimilarFilms) {
+				r.EXPECT().Similar(gomock.Any(), "1", p).Return([]models.FilmHit{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"items":[]}`,
+		},
+		{
+			name:                 "Invalid Limit",
+			query:                "?limit=many",
+			mockBehavior:         func(r *mock_restapi.MockFilmService, p m.SimilarFilms) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid limit","fields":{"limit":"must be a whole number"}}`,
+		},
+		{
+			name:  "Not Found",
+			input: m.SimilarFilms{Limit: 10},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SimilarFilms) {
+				r.EXPECT().Similar(gomock.Any(), "1", p).Return(nil, internal.NewErrorf(internal.ErrorCodeNotFound, "find film"))
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"error":"similar failed: find film"}`,
+		},
+		{
+			name:  "Service Error",
+			input: m.SimilarFilms{Limit: 10},
+			mockBehavior: func(r *mock_restapi.MockFilmService, p m.SimilarFilms) {
+				r.EXPECT().Similar(gomock.Any(), "1", p).Return(nil, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockFilmService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewFilmHandler(svc, NewCursors([]byte("secret"))).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/films/1/similar"+tt.query, bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
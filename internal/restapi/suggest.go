@@ -0,0 +1,73 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+const defaultSuggestLimit = 5
+
+//go:generate mockgen -source=suggest.go -destination=mock_restapi/mocksuggest.go
+
+// SuggestService
+type SuggestService interface {
+	Suggest(ctx context.Context, p m.Suggest) (models.Suggestions, error)
+}
+
+// SuggestHandler
+type SuggestHandler struct {
+	svc SuggestService
+}
+
+// NewSuggestHandler ...
+func NewSuggestHandler(svc SuggestService) *SuggestHandler {
+	return &SuggestHandler{
+		svc: svc,
+	}
+}
+
+func (h *SuggestHandler) Register(r *mux.Router) {
+	r.HandleFunc("/suggest", h.suggest).Methods(http.MethodGet)
+}
+
+//	@Tags Search
+//
+// @Description	suggest films and actors while their name is being typed, best matches first
+// @Produce		json
+// @Param		q		query		string		true	"Text typed so far, every word of it must start a word of the name"
+// @Param		limit	query		int			false	"Number of films and of actors, 1 to 20"	default(5)
+// @Success		200		{object}	models.Suggestions	"ok"
+// @Failure		400		{object}	ErrorResponse	"Bad request, fields holds the invalid parameters"
+// @Failure		500		{object}	internal.Error	"Internal error"
+// @Router		/suggest [get]
+func (h *SuggestHandler) suggest(w http.ResponseWriter, r *http.Request) {
+	p := m.Suggest{
+		Query: r.URL.Query().Get("q"),
+	}
+
+	var err error
+	if p.Limit, err = parseInt(r.URL.Query(), "limit", defaultSuggestLimit); err != nil {
+		msg := fmt.Errorf("invalid request %w",
+			internal.WrapErrorf(m.FieldErrors{"limit": "must be a whole number"}, internal.ErrorCodeInvalidArgument, "query"))
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	suggestions, err := h.svc.Suggest(r.Context(), p)
+	if err != nil {
+		msg := fmt.Errorf("suggest failed: %w", err)
+		renderErrorResponse(w, msg.Error(), msg)
+		return
+	}
+
+	renderResponse(w,
+		suggestions,
+		http.StatusOK)
+}
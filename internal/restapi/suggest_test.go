@@ -0,0 +1,113 @@
+package restapi
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	"filmoteka/internal/restapi/mock_restapi"
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestHandler_Suggest(t *testing.T) {
+	// Init Test Table
+	type mockBehavior func(r *mock_restapi.MockSuggestService, p m.Suggest)
+
+	suggestions := models.Suggestions{
+		Films:  []models.Suggestion{{Id: 1, Name: "Pirates of the Caribbean"}},
+		Actors: []models.Suggestion{{Id: 2, Name: "Johnny Depp"}},
+	}
+
+	tests := []struct {
+		name                 string
+		query                string
+		input                m.Suggest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "?q=pira",
+			input: m.Suggest{Query: "pira", Limit: 5},
+			mockBehavior: func(r *mock_restapi.MockSuggestService, p m.Suggest) {
+				r.EXPECT().Suggest(gomock.Any(), p).Return(suggestions, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"films":[{"id":1,"name":"Pirates of the Caribbean"}],"actors":[{"id":2,"name":"Johnny Depp"}]}`,
+		},
+		{
+			name:  "Limit",
+			query: "?q=jo&limit=1",
+			input: m.Suggest{Query: "jo", Limit: 1},
+			mockBehavior: func(r *mock_restapi.MockSuggestService, p m.Suggest) {
+				r.EXPECT().Suggest(gomock.Any(), p).Return(models.Suggestions{
+					Films:  []models.Suggestion{},
+					Actors: suggestions.Actors,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"films":[],"actors":[{"id":2,"name":"Johnny Depp"}]}`,
+		},
+		{
+			name:                 "Invalid Limit",
+			query:                "?q=jo&limit=all",
+			mockBehavior:         func(r *mock_restapi.MockSuggestService, p m.Suggest) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"invalid request query: invalid limit","fields":{"limit":"must be a whole number"}}`,
+		},
+		{
+			name:  "Missing Query",
+			query: "",
+			input: m.Suggest{Limit: 5},
+			mockBehavior: func(r *mock_restapi.MockSuggestService, p m.Suggest) {
+				r.EXPECT().Suggest(gomock.Any(), p).Return(models.Suggestions{},
+					internal.WrapErrorf(m.FieldErrors{"q": "is required"},
+						internal.ErrorCodeInvalidArgument, "validate suggest"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"suggest failed: validate suggest: invalid q","fields":{"q":"is required"}}`,
+		},
+		{
+			name:  "Service Error",
+			query: "?q=pira",
+			input: m.Suggest{Query: "pira", Limit: 5},
+			mockBehavior: func(r *mock_restapi.MockSuggestService, p m.Suggest) {
+				r.EXPECT().Suggest(gomock.Any(), p).Return(models.Suggestions{}, errors.New(`internal error`))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mux.NewRouter()
+			svc := mock_restapi.NewMockSuggestService(c)
+			tt.mockBehavior(svc, tt.input)
+			NewSuggestHandler(svc).Register(r)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/suggest"+tt.query, bytes.NewBufferString(""))
+
+			// Make Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, tt.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), tt.expectedResponseBody)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"strings"
 
 	"filmoteka/internal"
@@ -21,20 +23,26 @@ func NewActor(db *sql.DB) *ActorRepository {
 	}
 }
 
-// Create inserts a new Actor record.
-func (r *ActorRepository) Create(a m.CreateActor) (models.Actor, error) {
-	var id int
-	if err := r.db.QueryRow(
-		"INSERT INTO actors (name, gender, birth_date) VALUES ($1, $2, $3) RETURNING id;",
-		a.Name,
-		a.Gender,
-		a.BirthDate,
-	).Scan(&id); err != nil {
-		if strings.Contains(err.Error(), "unique constraint") {
-			return models.Actor{}, internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "insert actor")
-		} else {
-			return models.Actor{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert actor")
+// Create inserts a new Actor record and the outbox event indexing it.
+func (r *ActorRepository) Create(ctx context.Context, a m.CreateActor) (models.Actor, error) {
+	var id, version int
+	if err := inTx(ctx, r.db, "insert actor", func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO actors (name, gender, birth_date) VALUES ($1, $2, $3) RETURNING id, version;",
+			a.Name,
+			a.Gender,
+			a.BirthDate,
+		).Scan(&id, &version); err != nil {
+			if strings.Contains(err.Error(), "unique constraint") {
+				return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "insert actor")
+			} else {
+				return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert actor")
+			}
 		}
+
+		return enqueue(ctx, tx, models.EntityActor, id, models.OperationIndex)
+	}); err != nil {
+		return models.Actor{}, err
 	}
 
 	return models.Actor{
@@ -42,34 +50,74 @@ func (r *ActorRepository) Create(a m.CreateActor) (models.Actor, error) {
 		Name:      a.Name,
 		Gender:    a.Gender,
 		BirthDate: a.BirthDate,
+		Version:   version,
 	}, nil
 }
 
-// Delete deletes the existing record matching the id.
-func (r *ActorRepository) Delete(id string) error {
-	result, err := r.db.Exec("DELETE FROM actors WHERE id=$1;", id)
-	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete actor")
+// Delete deletes the existing record matching the id, when version is not 0 only if it's the
+// current version. The outbox events removing it from the search index and reindexing its films
+// are written along.
+func (r *ActorRepository) Delete(ctx context.Context, id string, version int) error {
+	return inTx(ctx, r.db, "delete actor", func(tx *sql.Tx) error {
+		// NOTE: Before the cast is deleted along with the actor.
+		if err := enqueueCastFilms(ctx, tx, id); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM actors WHERE id=$1 AND ($2 = 0 OR version=$2);", id, version)
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete actor")
+		}
+
+		deletedRows, err := result.RowsAffected()
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete actor")
+		}
+		if deletedRows == 0 {
+			return versionMismatch(ctx, r.db, "actors", id, version, "delete actor")
+		}
+
+		return enqueue(ctx, tx, models.EntityActor, id, models.OperationDelete)
+	})
+}
+
+// SearchBy returns a page of the actors matching the filters in p together with the total
+// number of matching actors.
+func (r *ActorRepository) SearchBy(ctx context.Context, p m.ListActors) ([]models.Actor, int, error) {
+	var conds conditions
+	if p.Gender != "" {
+		conds.add("gender = ?", p.Gender)
+	}
+	if p.BornFrom != "" {
+		conds.add("birth_date >= ?", p.BornFrom)
+	}
+	if p.BornTo != "" {
+		conds.add("birth_date <= ?", p.BornTo)
 	}
 
-	deletedRows, err := result.RowsAffected()
-	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete actor")
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT count(*) FROM actors"+conds.where()+";",
+		conds.args...,
+	).Scan(&total); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count actors")
 	}
-	if deletedRows == 0 {
-		return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "delete actor")
+
+	if p.After != nil {
+		conds.addClause(conds.keyset(p.Sort, p.After))
 	}
 
-	return nil
-}
+	query := "SELECT id, name, gender, to_char(birth_date, 'YYYY-MM-DD'), version FROM actors" +
+		conds.where() +
+		orderBy(p.Sort) +
+		" LIMIT " + conds.placeholder(p.Limit) +
+		" OFFSET " + conds.placeholder(p.Offset) + ";"
 
-func (r *ActorRepository) SearchBy() ([]models.Actor, error) {
 	a := models.Actor{}
 	actors := make([]models.Actor, 0)
-	rows, err := r.db.Query(
-		"SELECT id, name, gender, birth_date FROM actors;")
+	rows, err := r.db.QueryContext(ctx, query, conds.args...)
 	if err != nil {
-		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
 	}
 	defer rows.Close()
 
@@ -79,26 +127,31 @@ func (r *ActorRepository) SearchBy() ([]models.Actor, error) {
 			&a.Name,
 			&a.Gender,
 			&a.BirthDate,
+			&a.Version,
 		)
 		if err != nil {
-			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+			return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
 		}
 		actors = append(actors, a)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+	}
 
-	return actors, nil
+	return actors, total, nil
 }
 
-func (r *ActorRepository) Find(id string) (models.Actor, error) {
+func (r *ActorRepository) Find(ctx context.Context, id string) (models.Actor, error) {
 	a := models.Actor{}
-	if err := r.db.QueryRow(
-		"SELECT id, name, gender, birth_date rating FROM actors WHERE id=$1",
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT id, name, gender, to_char(birth_date, 'YYYY-MM-DD'), version FROM actors WHERE id=$1",
 		id,
 	).Scan(
 		&a.Id,
 		&a.Name,
 		&a.Gender,
 		&a.BirthDate,
+		&a.Version,
 	); err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -111,25 +164,83 @@ func (r *ActorRepository) Find(id string) (models.Actor, error) {
 	return a, nil
 }
 
-func (r *ActorRepository) Update(id string, a m.UpdateActor) error {
-	result, err := r.db.Exec(
-		"UPDATE actors SET name=$1, gender=$2, birth_date=$3 WHERE id=$4;",
-		a.Name,
-		a.Gender,
-		a.BirthDate,
-		id,
-	)
+// Update replaces the actor matching the id, when version is not 0 only if it's the current
+// version, and returns its new version. The outbox events reindexing it and its films are written
+// along.
+func (r *ActorRepository) Update(ctx context.Context, id string, a m.UpdateActor, version int) (int, error) {
+	var updated int
+
+	err := inTx(ctx, r.db, "update actor", func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`UPDATE actors SET name=$1, gender=$2, birth_date=$3, version=version+1
+			WHERE id=$4 AND ($5 = 0 OR version=$5)
+			RETURNING version;`,
+			a.Name,
+			a.Gender,
+			a.BirthDate,
+			id,
+			version,
+		).Scan(&updated); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return versionMismatch(ctx, r.db, "actors", id, version, "update actor")
+			}
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update actor")
+		}
+
+		if err := enqueue(ctx, tx, models.EntityActor, id, models.OperationIndex); err != nil {
+			return err
+		}
+
+		return enqueueCastFilms(ctx, tx, id)
+	})
 	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update actor")
+		return 0, err
 	}
 
-	updatedRows, err := result.RowsAffected()
-	if err != nil {
-		return err
+	return updated, nil
+}
+
+// Patch updates only the columns of the fields set in a, when version is not 0 only if it's the
+// current version. The outbox events reindexing it and its films are written along.
+func (r *ActorRepository) Patch(ctx context.Context, id string, a m.PatchActor, version int) error {
+	var q conditions
+
+	sets := make([]string, 0, 3)
+	if a.Name != nil {
+		sets = append(sets, "name="+q.placeholder(*a.Name))
+	}
+	if a.Gender != nil {
+		sets = append(sets, "gender="+q.placeholder(*a.Gender))
+	}
+	if a.BirthDate != nil {
+		sets = append(sets, "birth_date="+q.placeholder(*a.BirthDate))
 	}
-	if updatedRows == 0 {
-		return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "update actor")
+	if len(sets) == 0 {
+		return nil
 	}
 
-	return nil
+	v := q.placeholder(version)
+	query := "UPDATE actors SET " + strings.Join(sets, ", ") + ", version=version+1" +
+		" WHERE id=" + q.placeholder(id) + " AND (" + v + " = 0 OR version=" + v + ");"
+
+	return inTx(ctx, r.db, "patch actor", func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, q.args...)
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "patch actor")
+		}
+
+		updatedRows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updatedRows == 0 {
+			return versionMismatch(ctx, r.db, "actors", id, version, "patch actor")
+		}
+
+		if err := enqueue(ctx, tx, models.EntityActor, id, models.OperationIndex); err != nil {
+			return err
+		}
+
+		return enqueueCastFilms(ctx, tx, id)
+	})
 }
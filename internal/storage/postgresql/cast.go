@@ -0,0 +1,200 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// CastRepository represents the repository used for interacting with the films to actors links.
+type CastRepository struct {
+	db *sql.DB
+}
+
+// NewCast instantiates the Cast repository.
+func NewCast(db *sql.DB) *CastRepository {
+	return &CastRepository{
+		db: db,
+	}
+}
+
+// Create links an actor to a film, together with the outbox event reindexing the film.
+func (r *CastRepository) Create(ctx context.Context, filmId string, c m.CreateCast) (models.CastMember, error) {
+	cm := models.CastMember{}
+	if err := inTx(ctx, r.db, "insert cast", func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`WITH ins AS (
+				INSERT INTO film_actors (film_id, actor_id, character_name, billing_order)
+				VALUES ($1, $2, $3, $4)
+				RETURNING actor_id, character_name, billing_order
+			)
+			SELECT ins.actor_id, a.name, ins.character_name, ins.billing_order
+			FROM ins JOIN actors a ON a.id = ins.actor_id;`,
+			filmId,
+			c.ActorId,
+			c.CharacterName,
+			c.BillingOrder,
+		).Scan(
+			&cm.ActorId,
+			&cm.Name,
+			&cm.CharacterName,
+			&cm.BillingOrder,
+		); err != nil {
+			switch {
+			case strings.Contains(err.Error(), "unique constraint"):
+				return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "insert cast")
+			case strings.Contains(err.Error(), "foreign key constraint"):
+				return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "insert cast")
+			default:
+				return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert cast")
+			}
+		}
+
+		return enqueue(ctx, tx, models.EntityFilm, filmId, models.OperationIndex)
+	}); err != nil {
+		return models.CastMember{}, err
+	}
+
+	return cm, nil
+}
+
+// Delete unlinks an actor from a film, together with the outbox event reindexing the film.
+func (r *CastRepository) Delete(ctx context.Context, filmId, actorId string) error {
+	return inTx(ctx, r.db, "delete cast", func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM film_actors WHERE film_id=$1 AND actor_id=$2;", filmId, actorId)
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete cast")
+		}
+
+		deletedRows, err := result.RowsAffected()
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete cast")
+		}
+		if deletedRows == 0 {
+			return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "delete cast")
+		}
+
+		return enqueue(ctx, tx, models.EntityFilm, filmId, models.OperationIndex)
+	})
+}
+
+// Update changes the character name and billing order of an actor in a film.
+func (r *CastRepository) Update(ctx context.Context, filmId, actorId string, c m.UpdateCast) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE film_actors SET character_name=$1, billing_order=$2 WHERE film_id=$3 AND actor_id=$4;",
+		c.CharacterName,
+		c.BillingOrder,
+		filmId,
+		actorId,
+	)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update cast")
+	}
+
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updatedRows == 0 {
+		return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "update cast")
+	}
+
+	return nil
+}
+
+// FindByFilm returns the cast of a film ordered by billing.
+func (r *CastRepository) FindByFilm(ctx context.Context, filmId string) ([]models.CastMember, error) {
+	if err := r.exists(ctx, "films", filmId); err != nil {
+		return nil, err
+	}
+
+	cm := models.CastMember{}
+	cast := make([]models.CastMember, 0)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT a.id, a.name, fa.character_name, fa.billing_order
+		FROM film_actors fa JOIN actors a ON a.id = fa.actor_id
+		WHERE fa.film_id=$1
+		ORDER BY fa.billing_order, a.name;`,
+		filmId,
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find cast")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(
+			&cm.ActorId,
+			&cm.Name,
+			&cm.CharacterName,
+			&cm.BillingOrder,
+		)
+		if err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find cast")
+		}
+		cast = append(cast, cm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find cast")
+	}
+
+	return cast, nil
+}
+
+// FindByActor returns the films an actor is credited in, newest first.
+func (r *CastRepository) FindByActor(ctx context.Context, actorId string) ([]models.Role, error) {
+	if err := r.exists(ctx, "actors", actorId); err != nil {
+		return nil, err
+	}
+
+	role := models.Role{}
+	roles := make([]models.Role, 0)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT f.id, f.name, f.release_year, fa.character_name, fa.billing_order
+		FROM film_actors fa JOIN films f ON f.id = fa.film_id
+		WHERE fa.actor_id=$1
+		ORDER BY f.release_year DESC, f.name;`,
+		actorId,
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find roles")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(
+			&role.FilmId,
+			&role.Name,
+			&role.ReleaseYear,
+			&role.CharacterName,
+			&role.BillingOrder,
+		)
+		if err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find roles")
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find roles")
+	}
+
+	return roles, nil
+}
+
+// exists returns a not found error when table has no row matching the id, so that listing
+// the cast of a missing film is told apart from a film without cast.
+func (r *CastRepository) exists(ctx context.Context, table, id string) error {
+	found, err := rowExists(ctx, r.db, table, id)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "find %s", table)
+	}
+	if !found {
+		return internal.NewErrorf(internal.ErrorCodeNotFound, "find %s", table)
+	}
+
+	return nil
+}
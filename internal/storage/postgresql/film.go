@@ -1,9 +1,13 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"strings"
 
+	"github.com/lib/pq"
+
 	"filmoteka/internal"
 	"filmoteka/internal/app/models"
 	m "filmoteka/internal/restapi/models"
@@ -21,21 +25,27 @@ func NewFilm(db *sql.DB) *FilmRepository {
 	}
 }
 
-// Create inserts a new Film record.
-func (r *FilmRepository) Create(f m.CreateFilm) (models.Film, error) {
-	var id int
-	if err := r.db.QueryRow(
-		"INSERT INTO films (name, description, release_year, rating) VALUES ($1, $2, $3, $4) RETURNING id;",
-		f.Name,
-		f.Description,
-		f.ReleaseYear,
-		f.Rating,
-	).Scan(&id); err != nil {
-		if strings.Contains(err.Error(), "unique constraint") {
-			return models.Film{}, internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "insert film")
-		} else {
-			return models.Film{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert film")
+// Create inserts a new Film record, together with the outbox event indexing it.
+func (r *FilmRepository) Create(ctx context.Context, f m.CreateFilm) (models.Film, error) {
+	var id, version int
+	if err := inTx(ctx, r.db, "insert film", func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO films (name, description, release_year, rating) VALUES ($1, $2, $3, $4) RETURNING id, version;",
+			f.Name,
+			f.Description,
+			f.ReleaseYear,
+			f.Rating,
+		).Scan(&id, &version); err != nil {
+			if strings.Contains(err.Error(), "unique constraint") {
+				return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "insert film")
+			} else {
+				return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert film")
+			}
 		}
+
+		return enqueue(ctx, tx, models.EntityFilm, id, models.OperationIndex)
+	}); err != nil {
+		return models.Film{}, err
 	}
 
 	return models.Film{
@@ -44,34 +54,71 @@ func (r *FilmRepository) Create(f m.CreateFilm) (models.Film, error) {
 		Description: f.Description,
 		ReleaseYear: f.ReleaseYear,
 		Rating:      f.Rating,
+		Version:     version,
 	}, nil
 }
 
-// Delete deletes the existing record matching the id.
-func (r *FilmRepository) Delete(id string) error {
-	result, err := r.db.Exec("DELETE FROM films WHERE id=$1;", id)
-	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete film")
+// Delete deletes the existing record matching the id, when version is not 0 only if it's the
+// current version. The outbox event removing it from the search index is written along.
+func (r *FilmRepository) Delete(ctx context.Context, id string, version int) error {
+	return inTx(ctx, r.db, "delete film", func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM films WHERE id=$1 AND ($2 = 0 OR version=$2);", id, version)
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete film")
+		}
+
+		deletedRows, err := result.RowsAffected()
+		if err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete film")
+		}
+		if deletedRows == 0 {
+			return versionMismatch(ctx, r.db, "films", id, version, "delete film")
+		}
+
+		return enqueue(ctx, tx, models.EntityFilm, id, models.OperationDelete)
+	})
+}
+
+// FindAll returns a page of the films matching the filters in p together with the total
+// number of matching films.
+func (r *FilmRepository) FindAll(ctx context.Context, p m.ListFilms) ([]models.Film, int, error) {
+	var conds conditions
+	if p.YearFrom != nil {
+		conds.add("release_year >= ?", *p.YearFrom)
+	}
+	if p.YearTo != nil {
+		conds.add("release_year <= ?", *p.YearTo)
+	}
+	if p.RatingFrom != nil {
+		conds.add("rating >= ?", *p.RatingFrom)
+	}
+	if p.RatingTo != nil {
+		conds.add("rating <= ?", *p.RatingTo)
 	}
 
-	deletedRows, err := result.RowsAffected()
-	if err != nil {
-		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "delete film")
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT count(*) FROM films"+conds.where()+";",
+		conds.args...,
+	).Scan(&total); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count films")
 	}
-	if deletedRows == 0 {
-		return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "delete film")
+
+	if p.After != nil {
+		conds.addClause(conds.keyset(p.Sort, p.After))
 	}
 
-	return nil
-}
+	query := "SELECT id, name, description, release_year, rating, version FROM films" +
+		conds.where() +
+		orderBy(p.Sort) +
+		" LIMIT " + conds.placeholder(p.Limit) +
+		" OFFSET " + conds.placeholder(p.Offset) + ";"
 
-func (r *FilmRepository) FindAll() ([]models.Film, error) {
 	f := &models.Film{}
 	films := make([]models.Film, 0)
-	rows, err := r.db.Query(
-		"SELECT id, name, description, release_year, rating FROM films;")
+	rows, err := r.db.QueryContext(ctx, query, conds.args...)
 	if err != nil {
-		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
 	}
 	defer rows.Close()
 
@@ -82,20 +129,63 @@ func (r *FilmRepository) FindAll() ([]models.Film, error) {
 			&f.Description,
 			&f.ReleaseYear,
 			&f.Rating,
+			&f.Version,
 		)
 		if err != nil {
-			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+			return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
 		}
 		films = append(films, *f)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search by")
+	}
+
+	return films, total, nil
+}
+
+// castNames selects the names of the cast of the films row in billing order, films are indexed
+// with them.
+const castNames = `ARRAY(SELECT a.name FROM film_actors fa JOIN actors a ON a.id = fa.actor_id
+	WHERE fa.film_id = films.id ORDER BY fa.billing_order, a.id)`
+
+// Walk calls fn for every film in id order, rows are read as they are consumed. It stops at the
+// first error returned by fn.
+func (r *FilmRepository) Walk(ctx context.Context, fn func(models.Film) error) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, description, release_year, rating, version, "+castNames+" FROM films ORDER BY id;")
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "walk films")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f models.Film
+		if err := rows.Scan(
+			&f.Id,
+			&f.Name,
+			&f.Description,
+			&f.ReleaseYear,
+			&f.Rating,
+			&f.Version,
+			pq.Array(&f.Actors),
+		); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "walk films")
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "walk films")
+	}
 
-	return films, nil
+	return nil
 }
 
-func (r *FilmRepository) Find(id string) (models.Film, error) {
+// Find returns the film matching the id, with the names of its cast.
+func (r *FilmRepository) Find(ctx context.Context, id string) (models.Film, error) {
 	f := models.Film{}
-	if err := r.db.QueryRow(
-		"SELECT id, name, description, release_year, rating FROM films WHERE id=$1",
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT id, name, description, release_year, rating, version, "+castNames+" FROM films WHERE id=$1",
 		id,
 	).Scan(
 		&f.Id,
@@ -103,6 +193,8 @@ func (r *FilmRepository) Find(id string) (models.Film, error) {
 		&f.Description,
 		&f.ReleaseYear,
 		&f.Rating,
+		&f.Version,
+		pq.Array(&f.Actors),
 	); err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -115,30 +207,86 @@ func (r *FilmRepository) Find(id string) (models.Film, error) {
 	return f, nil
 }
 
-func (r *FilmRepository) Update(id string, f m.UpdateFilm) error {
-	result, err := r.db.Exec(
-		"UPDATE films SET name=$1, description=$2, release_year=$3, rating=$4 WHERE id=$5;",
-		f.Name,
-		f.Description,
-		f.ReleaseYear,
-		f.Rating,
-		id,
-	)
-	if err != nil {
-		if strings.Contains(err.Error(), "unique constraint") {
-			return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "update film")
-		} else {
-			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update film")
+// Update replaces the film matching the id, when version is not 0 only if it's the current
+// version, and returns its new version. The outbox event reindexing it is written along.
+func (r *FilmRepository) Update(ctx context.Context, id string, f m.UpdateFilm, version int) (int, error) {
+	var updated int
+
+	err := inTx(ctx, r.db, "update film", func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`UPDATE films SET name=$1, description=$2, release_year=$3, rating=$4, version=version+1
+			WHERE id=$5 AND ($6 = 0 OR version=$6)
+			RETURNING version;`,
+			f.Name,
+			f.Description,
+			f.ReleaseYear,
+			f.Rating,
+			id,
+			version,
+		).Scan(&updated); err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return versionMismatch(ctx, r.db, "films", id, version, "update film")
+			case strings.Contains(err.Error(), "unique constraint"):
+				return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "update film")
+			default:
+				return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update film")
+			}
 		}
-	}
 
-	updatedRows, err := result.RowsAffected()
+		return enqueue(ctx, tx, models.EntityFilm, id, models.OperationIndex)
+	})
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// Patch updates only the columns of the fields set in f, when version is not 0 only if it's the
+// current version. The outbox event reindexing it is written along.
+func (r *FilmRepository) Patch(ctx context.Context, id string, f m.PatchFilm, version int) error {
+	var q conditions
+
+	sets := make([]string, 0, 4)
+	if f.Name != nil {
+		sets = append(sets, "name="+q.placeholder(*f.Name))
+	}
+	if f.Description != nil {
+		sets = append(sets, "description="+q.placeholder(*f.Description))
 	}
-	if updatedRows == 0 {
-		return internal.WrapErrorf(err, internal.ErrorCodeNotFound, "update film")
+	if f.ReleaseYear != nil {
+		sets = append(sets, "release_year="+q.placeholder(*f.ReleaseYear))
+	}
+	if f.Rating != nil {
+		sets = append(sets, "rating="+q.placeholder(*f.Rating))
+	}
+	if len(sets) == 0 {
+		return nil
 	}
 
-	return nil
+	v := q.placeholder(version)
+	query := "UPDATE films SET " + strings.Join(sets, ", ") + ", version=version+1" +
+		" WHERE id=" + q.placeholder(id) + " AND (" + v + " = 0 OR version=" + v + ");"
+
+	return inTx(ctx, r.db, "patch film", func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, q.args...)
+		if err != nil {
+			if strings.Contains(err.Error(), "unique constraint") {
+				return internal.WrapErrorf(err, internal.ErrorCodeUniqueConstraints, "patch film")
+			} else {
+				return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "patch film")
+			}
+		}
+
+		updatedRows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updatedRows == 0 {
+			return versionMismatch(ctx, r.db, "films", id, version, "patch film")
+		}
+
+		return enqueue(ctx, tx, models.EntityFilm, id, models.OperationIndex)
+	})
 }
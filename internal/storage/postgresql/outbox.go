@@ -0,0 +1,261 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+)
+
+// OutboxRepository represents the repository used for interacting with the search outbox, the
+// changes waiting to be delivered to the search index.
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutbox instantiates the Outbox repository.
+func NewOutbox(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// Claim returns up to limit events due for delivery. Claimed events are hidden from other
+// claims for lease, so they are delivered again if the claimer dies before acknowledging them.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE search_outbox SET attempts=attempts+1, next_attempt_at=now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM search_outbox WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, entity, entity_id, operation, attempts, created_at;`,
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "claim outbox")
+	}
+	defer rows.Close()
+
+	events := make([]models.OutboxEvent, 0, limit)
+	for rows.Next() {
+		var e models.OutboxEvent
+		if err := rows.Scan(&e.Id, &e.Entity, &e.EntityId, &e.Operation, &e.Attempts, &e.CreatedAt); err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "claim outbox")
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "claim outbox")
+	}
+
+	return events, nil
+}
+
+// Delivered marks an event delivered, it is kept until purged, see ChangedFilms.
+func (r *OutboxRepository) Delivered(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, "UPDATE search_outbox SET delivered_at=now() WHERE id=$1;", id); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update outbox")
+	}
+
+	return nil
+}
+
+// Failed records the cause of a failed delivery, the event is due again after retryIn.
+func (r *OutboxRepository) Failed(ctx context.Context, id int64, cause string, retryIn time.Duration) error {
+	if _, err := r.db.ExecContext(ctx,
+		"UPDATE search_outbox SET last_error=$2, next_attempt_at=now() + make_interval(secs => $3) WHERE id=$1;",
+		id,
+		cause,
+		retryIn.Seconds(),
+	); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update outbox")
+	}
+
+	return nil
+}
+
+// Dead parks an event which failed every attempt, recording the cause of the last failure. It is
+// not claimed anymore.
+func (r *OutboxRepository) Dead(ctx context.Context, id int64, cause string) error {
+	if _, err := r.db.ExecContext(ctx,
+		"UPDATE search_outbox SET last_error=$2, dead_at=now() WHERE id=$1;",
+		id,
+		cause,
+	); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "update outbox")
+	}
+
+	return nil
+}
+
+// Stats returns the number and the age of the pending events, and the number of dead ones.
+func (r *OutboxRepository) Stats(ctx context.Context) (models.OutboxStats, error) {
+	var s models.OutboxStats
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT count(*) FILTER (WHERE dead_at IS NULL),
+		count(*) FILTER (WHERE dead_at IS NULL AND last_error IS NOT NULL),
+		COALESCE(EXTRACT(EPOCH FROM now() - min(created_at) FILTER (WHERE dead_at IS NULL)), 0),
+		count(*) FILTER (WHERE dead_at IS NOT NULL)
+		FROM search_outbox WHERE delivered_at IS NULL;`,
+	).Scan(&s.Pending, &s.Retrying, &s.LagSeconds, &s.Dead); err != nil {
+		return models.OutboxStats{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "outbox stats")
+	}
+
+	return s, nil
+}
+
+// Purge removes the events delivered more than retention ago, unless the search index is being
+// rebuilt, see Lock.
+func (r *OutboxRepository) Purge(ctx context.Context, retention time.Duration) error {
+	return inTx(ctx, r.db, "purge outbox", func(tx *sql.Tx) error {
+		var unlocked bool
+		if err := tx.QueryRowContext(ctx,
+			"SELECT pg_try_advisory_xact_lock($1);",
+			rebuildLock,
+		).Scan(&unlocked); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "purge outbox")
+		}
+		if !unlocked {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM search_outbox WHERE delivered_at < now() - make_interval(secs => $1);",
+			retention.Seconds(),
+		); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "purge outbox")
+		}
+
+		return nil
+	})
+}
+
+// rebuildLock is the key of the advisory lock held while the search index is rebuilt.
+const rebuildLock = 4_207_313
+
+// Lock takes the lock held while the search index is rebuilt: delivered events aren't purged
+// while it's held, so that the rebuild can replay them, and no other rebuild runs. It fails when
+// the lock is held already, the returned func releases it.
+func (r *OutboxRepository) Lock(ctx context.Context) (func() error, error) {
+	// NOTE: Advisory locks belong to the session, the lock is released too when the process dies.
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "lock outbox")
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1);", rebuildLock).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "lock outbox")
+	}
+	if !locked {
+		conn.Close()
+		return nil, internal.NewErrorf(internal.ErrorCodeUnavailable, "lock outbox: the search index is being rebuilt")
+	}
+
+	return func() error {
+		defer conn.Close()
+
+		// NOTE: Not ctx, the lock must be released even when the rebuild was cancelled.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", rebuildLock); err != nil {
+			return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "unlock outbox")
+		}
+
+		return nil
+	}, nil
+}
+
+// Position returns the position of the outbox: the events written by the transactions not
+// committed yet, and by the later ones, come after it.
+func (r *OutboxRepository) Position(ctx context.Context) (int64, error) {
+	var position int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT txid_snapshot_xmin(txid_current_snapshot());",
+	).Scan(&position); err != nil {
+		return 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "outbox position")
+	}
+
+	return position, nil
+}
+
+// ChangedFilms returns the ids of the films changed from the position on, delivered or not. It
+// may return films changed a little before the position too.
+func (r *OutboxRepository) ChangedFilms(ctx context.Context, position int64) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT DISTINCT entity_id FROM search_outbox WHERE entity=$1 AND txid >= $2 ORDER BY entity_id;",
+		models.EntityFilm,
+		position,
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "changed films")
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "changed films")
+		}
+		ids = append(ids, strconv.Itoa(id))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "changed films")
+	}
+
+	return ids, nil
+}
+
+// enqueue records in tx that the search index of the entity must be brought up to date.
+func enqueue(ctx context.Context, tx *sql.Tx, entity string, id interface{}, operation string) error {
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO search_outbox (entity, entity_id, operation) VALUES ($1, $2, $3);",
+		entity,
+		id,
+		operation,
+	); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert outbox")
+	}
+
+	return nil
+}
+
+// enqueueCastFilms records in tx that the films of the actor must be reindexed, the film
+// documents hold the names of their cast.
+func enqueueCastFilms(ctx context.Context, tx *sql.Tx, actorId interface{}) error {
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO search_outbox (entity, entity_id, operation) SELECT $1, film_id, $2 FROM film_actors WHERE actor_id=$3;",
+		models.EntityFilm,
+		models.OperationIndex,
+		actorId,
+	); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, "insert outbox")
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction, which is committed only when fn succeeds. The transaction is
+// rolled back when ctx is cancelled before it's committed.
+func inTx(ctx context.Context, db *sql.DB, op string, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, op)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"filmoteka/internal"
+	m "filmoteka/internal/restapi/models"
+)
+
+// conditions accumulates the WHERE clauses of a query together with their arguments.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends a clause, every `?` in it is replaced by the placeholder of arg.
+func (c *conditions) add(clause string, arg interface{}) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, strings.ReplaceAll(clause, "?", "$"+strconv.Itoa(len(c.args))))
+}
+
+// addClause appends a clause whose placeholders were already taken with placeholder.
+func (c *conditions) addClause(clause string) {
+	c.clauses = append(c.clauses, clause)
+}
+
+// where returns the WHERE clause, if any.
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// placeholder appends arg and returns its placeholder.
+func (c *conditions) placeholder(arg interface{}) string {
+	c.args = append(c.args, arg)
+
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+// sortColumns are the expressions sorted on for the sort keys which aren't sorted on their
+// column. Ratings are double precision but cursors, like models.Film, hold them as real: sorting
+// and comparing them as real keeps the keyset exact, see keyset.
+var sortColumns = map[string]string{
+	"rating": "rating::real",
+}
+
+// sortColumn returns the expression sorted on for the sort key name.
+func sortColumn(name string) string {
+	if column, ok := sortColumns[name]; ok {
+		return column
+	}
+
+	return name
+}
+
+// orderBy returns the ORDER BY clause for sort. Fields are expected to be validated already.
+func orderBy(sort []m.SortField) string {
+	keys := make([]string, 0, len(sort)+1)
+	for _, s := range m.StableSort(sort) {
+		if s.Desc {
+			keys = append(keys, sortColumn(s.Name)+" DESC")
+		} else {
+			keys = append(keys, sortColumn(s.Name)+" ASC")
+		}
+	}
+
+	return " ORDER BY " + strings.Join(keys, ", ")
+}
+
+// keyset returns the clause selecting the rows ordered after the row whose sort key values
+// are after, i.e. `(k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...` with `<` for descending keys.
+func (c *conditions) keyset(sort []m.SortField, after []interface{}) string {
+	sort = m.StableSort(sort)
+
+	ors := make([]string, 0, len(sort))
+	for i, s := range sort {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, sortColumn(sort[j].Name)+" = "+c.placeholder(after[j]))
+		}
+
+		op := " > "
+		if s.Desc {
+			op = " < "
+		}
+		ands = append(ands, sortColumn(s.Name)+op+c.placeholder(after[i]))
+
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// rowExists reports whether table has a row matching the id. table is never user input.
+func rowExists(ctx context.Context, db *sql.DB, table, id string) (bool, error) {
+	var found bool
+	if err := db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id=$1);",
+		id,
+	).Scan(&found); err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// versionMismatch returns the error of a conditional write that changed no rows: not found when
+// the row is missing, precondition failed when it exists but version is not the current one.
+func versionMismatch(ctx context.Context, db *sql.DB, table, id string, version int, op string) error {
+	if version == 0 {
+		return internal.NewErrorf(internal.ErrorCodeNotFound, op)
+	}
+
+	found, err := rowExists(ctx, db, table, id)
+	if err != nil {
+		return internal.WrapErrorf(err, internal.ErrorCodeUnknown, op)
+	}
+	if !found {
+		return internal.NewErrorf(internal.ErrorCodeNotFound, op)
+	}
+
+	return internal.NewErrorf(internal.ErrorCodePreconditionFailed, "%s: version %d is not current", op, version)
+}
@@ -0,0 +1,252 @@
+package postgresql
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	m "filmoteka/internal/restapi/models"
+)
+
+func TestConditions_keyset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sort   []m.SortField
+		after  []interface{}
+		clause string
+		args   []interface{}
+	}{
+		{
+			name:   "id",
+			after:  []interface{}{3},
+			clause: "((id > $1))",
+			args:   []interface{}{3},
+		},
+		{
+			name:   "descending with id",
+			sort:   []m.SortField{{Name: "rating", Desc: true}},
+			after:  []interface{}{float32(8), 3},
+			clause: "((rating::real < $1) OR (rating::real = $2 AND id > $3))",
+			args:   []interface{}{float32(8), float32(8), 3},
+		},
+		{
+			name:   "mixed directions",
+			sort:   []m.SortField{{Name: "release_year"}, {Name: "name", Desc: true}},
+			after:  []interface{}{2003, "Film", 3},
+			clause: "((release_year > $1) OR (release_year = $2 AND name < $3) OR (release_year = $4 AND name = $5 AND id > $6))",
+			args:   []interface{}{2003, 2003, "Film", 2003, "Film", 3},
+		},
+		{
+			name:   "explicit id",
+			sort:   []m.SortField{{Name: "id", Desc: true}, {Name: "name"}},
+			after:  []interface{}{3},
+			clause: "((id < $1))",
+			args:   []interface{}{3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var conds conditions
+
+			assert.Equal(t, tc.clause, conds.keyset(tc.sort, tc.after))
+			assert.Equal(t, tc.args, conds.args)
+		})
+	}
+}
+
+// row is a film of the keyset paging test, by column.
+type row map[string]interface{}
+
+// value evaluates the sort expression, see sortColumn, for r. Ratings are double precision in
+// Postgres, float64 here.
+func value(r row, expr string) interface{} {
+	name, typ, _ := strings.Cut(expr, "::")
+	if typ == "real" {
+		return scanned(r[name])
+	}
+
+	return r[name]
+}
+
+// scanned returns the value of a column as scanned into models.Film, whose ratings are float32.
+func scanned(v interface{}) interface{} {
+	if f, ok := v.(float64); ok {
+		return float32(f)
+	}
+
+	return v
+}
+
+// param returns arg as Postgres compares it with v: float32 arguments are sent as float64 and
+// read as real only when compared with a real.
+func param(v, arg interface{}) interface{} {
+	if f, ok := arg.(float32); ok {
+		if _, ok := v.(float64); ok {
+			return float64(f)
+		}
+	}
+
+	return arg
+}
+
+// less compares the values of a column.
+func less(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int:
+		return a < b.(int)
+	case float32:
+		return a < b.(float32)
+	case float64:
+		return a < b.(float64)
+	default:
+		return a.(string) < b.(string)
+	}
+}
+
+// matches evaluates a keyset clause, see conditions.keyset, for r.
+func matches(t *testing.T, clause string, args []interface{}, r row) bool {
+	t.Helper()
+
+	clause = strings.TrimSuffix(strings.TrimPrefix(clause, "("), ")")
+
+	for _, or := range strings.Split(clause, " OR ") {
+		all := true
+		for _, and := range strings.Split(strings.Trim(or, "()"), " AND ") {
+			fields := strings.Fields(and)
+			require.Len(t, fields, 3)
+
+			n, err := strconv.Atoi(strings.TrimPrefix(fields[2], "$"))
+			require.NoError(t, err)
+
+			v := value(r, fields[0])
+			arg := param(v, args[n-1])
+			switch fields[1] {
+			case "=":
+				all = all && v == arg
+			case "<":
+				all = all && less(v, arg)
+			case ">":
+				all = all && less(arg, v)
+			}
+		}
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestConditions_keysetTies(t *testing.T) {
+	rows := []row{
+		{"id": 1, "rating": float32(8), "name": "B"},
+		{"id": 2, "rating": float32(7), "name": "A"},
+		{"id": 3, "rating": float32(8), "name": "A"},
+		{"id": 4, "rating": float32(8), "name": "B"},
+		{"id": 5, "rating": float32(7), "name": "A"},
+		{"id": 6, "rating": float32(9), "name": "C"},
+		{"id": 7, "rating": float32(8), "name": "A"},
+	}
+
+	testCases := []struct {
+		name string
+		sort []m.SortField
+		want []int
+	}{
+		{
+			name: "rating",
+			sort: []m.SortField{{Name: "rating", Desc: true}},
+			want: []int{6, 1, 3, 4, 7, 2, 5},
+		},
+		{
+			name: "rating and name",
+			sort: []m.SortField{{Name: "rating", Desc: true}, {Name: "name"}},
+			want: []int{6, 3, 7, 1, 4, 2, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, pageThrough(t, rows, tc.sort))
+		})
+	}
+}
+
+// TestConditions_keysetDoubleRatings pages through ratings which aren't exact in float32, the
+// precision of the ratings of the cursors.
+func TestConditions_keysetDoubleRatings(t *testing.T) {
+	rows := []row{
+		{"id": 1, "rating": 7.3},
+		{"id": 2, "rating": 8.1},
+		{"id": 3, "rating": 7.3},
+		{"id": 4, "rating": 6.9},
+		{"id": 5, "rating": 7.3},
+	}
+
+	// NOTE: Compared as double precision the cursor value would not match its own row.
+	require.NotEqual(t, 7.3, float64(float32(7.3)))
+
+	assert.Equal(t, []int{2, 1, 3, 5, 4}, pageThrough(t, rows, []m.SortField{{Name: "rating", Desc: true}}))
+	assert.Equal(t, []int{4, 1, 3, 5, 2}, pageThrough(t, rows, []m.SortField{{Name: "rating"}}))
+}
+
+// pageThrough returns the ids of rows in the order they are listed by pages of 2, each page
+// selected with the keyset clause of the last row of the previous page.
+func pageThrough(t *testing.T, rows []row, fields []m.SortField) []int {
+	t.Helper()
+
+	keys := m.StableSort(fields)
+
+	// NOTE: What ORDER BY returns.
+	ordered := append([]row(nil), rows...)
+	sort.Slice(ordered, func(i, j int) bool {
+		for _, k := range keys {
+			a, b := value(ordered[i], sortColumn(k.Name)), value(ordered[j], sortColumn(k.Name))
+			if a == b {
+				continue
+			}
+			return less(a, b) != k.Desc
+		}
+		return false
+	})
+
+	var (
+		got   []int
+		after []interface{}
+	)
+
+	// NOTE: Pages of 2 end in the middle of ties.
+	for page := 0; page < len(rows); page++ {
+		var conds conditions
+
+		var clause string
+		if after != nil {
+			clause = conds.keyset(fields, after)
+		}
+
+		n := 0
+		for _, r := range ordered {
+			if n == 2 || clause != "" && !matches(t, clause, conds.args, r) {
+				continue
+			}
+			got = append(got, r["id"].(int))
+			n++
+
+			// NOTE: The cursor holds the values of the last film.
+			after = make([]interface{}, len(keys))
+			for i, k := range keys {
+				after[i] = scanned(r[k.Name])
+			}
+		}
+		if n < 2 {
+			break
+		}
+	}
+
+	return got
+}
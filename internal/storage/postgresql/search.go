@@ -0,0 +1,394 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+
+	"filmoteka/internal"
+	"filmoteka/internal/app/models"
+	m "filmoteka/internal/restapi/models"
+)
+
+// FilmSearchRepository searches films with the Postgres full text search, it stands in for the
+// search index while it is unavailable. Films are matched on the `search` tsvector column, which
+// Postgres keeps up to date, and names also by trigram similarity to tolerate typos.
+type FilmSearchRepository struct {
+	db *sql.DB
+}
+
+// NewFilmSearch
+func NewFilmSearch(db *sql.DB) *FilmSearchRepository {
+	return &FilmSearchRepository{
+		db: db,
+	}
+}
+
+// Index does nothing, the search column is generated from the film.
+func (r *FilmSearchRepository) Index(_ context.Context, _ models.Film) error {
+	return nil
+}
+
+// Delete does nothing, the search column is deleted along with the film.
+func (r *FilmSearchRepository) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// Search returns a page of the films matching p, scored like the search index does as far as
+// Postgres allows: terms of q are alternatives ranked by ts_rank_cd with the boosts of p, plus the
+// similarity of the name when fuzzy. Fuzziness is either off, "0", or the trigram similarity
+// threshold of pg_trgm. No highlights are returned.
+func (r *FilmSearchRepository) Search(ctx context.Context, p m.SearchFilms) (models.FilmSearchResult, error) {
+	sort := m.StableSort(p.SearchSort())
+
+	var conds conditions
+	score := searchScore(&conds, p)
+	searchFilters(&conds, p, "")
+
+	inner := "SELECT id, name, coalesce(description, '') AS description, release_year, rating, version, " +
+		"(" + score + ")::real AS score FROM films" + conds.where()
+
+	conds.clauses = nil
+	if p.After != nil {
+		conds.addClause(conds.keyset(sort, p.After))
+	}
+
+	offset := (p.Page - 1) * p.Limit
+	if p.After != nil {
+		offset = 0
+	}
+
+	query := "SELECT id, name, description, release_year, rating, version, score FROM (" + inner + ") AS hits" +
+		conds.where() +
+		orderBy(sort) +
+		" LIMIT " + conds.placeholder(p.Limit) +
+		" OFFSET " + conds.placeholder(offset) + ";"
+
+	rows, err := r.db.QueryContext(ctx, query, conds.args...)
+	if err != nil {
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search films")
+	}
+	defer rows.Close()
+
+	res := models.FilmSearchResult{
+		Hits:    make([]models.FilmHit, 0, p.Limit),
+		Backend: models.SearchBackendPostgres,
+	}
+
+	for rows.Next() {
+		var (
+			hit   models.FilmHit
+			score float32
+		)
+		if err := rows.Scan(
+			&hit.Id,
+			&hit.Name,
+			&hit.Description,
+			&hit.ReleaseYear,
+			&hit.Rating,
+			&hit.Version,
+			&score,
+		); err != nil {
+			return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search films")
+		}
+		hit.Score = float64(score)
+
+		res.Hits = append(res.Hits, hit)
+		res.After = sortValues(sort, hit, score)
+	}
+	if err := rows.Err(); err != nil {
+		return models.FilmSearchResult{}, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "search films")
+	}
+
+	if res.Total, err = r.count(ctx, p); err != nil {
+		return models.FilmSearchResult{}, err
+	}
+
+	if res.Facets, err = r.facets(ctx, p); err != nil {
+		return models.FilmSearchResult{}, err
+	}
+
+	return res, nil
+}
+
+// count returns the number of films found by p, whatever the page: a window count over the
+// page query would be 0 for pages past the last film or after the last search_after.
+func (r *FilmSearchRepository) count(ctx context.Context, p m.SearchFilms) (int, error) {
+	var conds conditions
+	searchFilters(&conds, p, "")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT count(*) FROM films"+conds.where()+";",
+		conds.args...,
+	).Scan(&total); err != nil {
+		return 0, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count films")
+	}
+
+	return total, nil
+}
+
+// Similar returns up to limit films sharing words with the name and the description of the
+// film with the id, most similar first, ranked like Search plus the similarity of the names. The
+// film itself is left out; a missing film has no similar films.
+func (r *FilmSearchRepository) Similar(ctx context.Context, id string, limit int) ([]models.FilmHit, error) {
+	terms := strings.ReplaceAll(anyTerm, "?", "src.name || ' ' || coalesce(src.description, '')")
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT f.id, f.name, coalesce(f.description, ''), f.release_year, f.rating, f.version,
+			(ts_rank_cd(`+rankWeights(nil)+`, f.search, q.terms) + similarity(f.name, src.name))::real AS score
+		FROM films src
+		CROSS JOIN LATERAL (SELECT `+terms+` AS terms) q
+		JOIN films f ON f.id <> src.id AND f.search @@ q.terms
+		WHERE src.id = $1
+		ORDER BY score DESC, f.id
+		LIMIT $2;`,
+		id,
+		limit,
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "similar films")
+	}
+	defer rows.Close()
+
+	hits := make([]models.FilmHit, 0, limit)
+	for rows.Next() {
+		var (
+			hit   models.FilmHit
+			score float32
+		)
+		if err := rows.Scan(
+			&hit.Id,
+			&hit.Name,
+			&hit.Description,
+			&hit.ReleaseYear,
+			&hit.Rating,
+			&hit.Version,
+			&score,
+		); err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "similar films")
+		}
+		hit.Score = float64(score)
+
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "similar films")
+	}
+
+	return hits, nil
+}
+
+// facets counts the films found by p by decade and by rating bucket, each facet ignoring its own
+// selection like the search index does.
+func (r *FilmSearchRepository) facets(ctx context.Context, p m.SearchFilms) (map[string][]models.FacetBucket, error) {
+	decades := make([]string, len(p.Decades))
+	for i, d := range p.Decades {
+		decades[i] = strconv.Itoa(int(d))
+	}
+
+	var conds conditions
+	searchFilters(&conds, p, models.FacetDecade)
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT release_year / 10 * 10 AS decade, count(*) FROM films"+conds.where()+" GROUP BY decade ORDER BY decade;",
+		conds.args...,
+	)
+	if err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count decades")
+	}
+	defer rows.Close()
+
+	byDecade := make([]models.FacetBucket, 0)
+	for rows.Next() {
+		var b models.FacetBucket
+		if err := rows.Scan(&b.Key, &b.Count); err != nil {
+			return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count decades")
+		}
+		b.Selected = slices.Contains(decades, b.Key)
+		byDecade = append(byDecade, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count decades")
+	}
+
+	conds = conditions{}
+	searchFilters(&conds, p, models.FacetRating)
+
+	counts := make([]string, len(m.RatingRanges))
+	for i, rr := range m.RatingRanges {
+		counts[i] = "count(*) FILTER (WHERE " + ratingClause(&conds, rr) + ")"
+	}
+
+	byRating := make([]models.FacetBucket, len(m.RatingRanges))
+	dest := make([]interface{}, len(m.RatingRanges))
+	for i, rr := range m.RatingRanges {
+		byRating[i] = models.FacetBucket{
+			Key:      rr.Key,
+			Selected: slices.Contains(p.Ratings, rr.Key),
+		}
+		dest[i] = &byRating[i].Count
+	}
+
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT "+strings.Join(counts, ", ")+" FROM films"+conds.where()+";",
+		conds.args...,
+	).Scan(dest...); err != nil {
+		return nil, internal.WrapErrorf(err, internal.ErrorCodeUnknown, "count ratings")
+	}
+
+	return map[string][]models.FacetBucket{
+		models.FacetDecade: byDecade,
+		models.FacetRating: byRating,
+	}, nil
+}
+
+// anyTerm turns the tsquery of a plain text query into one matching any of its terms, the search
+// index matches q term by term too.
+const anyTerm = "replace(plainto_tsquery('english', ?)::text, ' & ', ' | ')::tsquery"
+
+// searchFilters adds the clauses selecting the films matching p, leaving out the selection of
+// the excluded facet, if any.
+func searchFilters(conds *conditions, p m.SearchFilms, excluded string) {
+	if p.Query != "" {
+		if fuzzy(p) {
+			conds.add("(search @@ "+anyTerm+" OR name % ?)", p.Query)
+		} else {
+			conds.add("search @@ "+anyTerm, p.Query)
+		}
+	}
+	for _, term := range p.Must {
+		conds.add("search @@ plainto_tsquery('english', ?)", term)
+	}
+	for _, phrase := range p.Phrases {
+		conds.add("search @@ phraseto_tsquery('english', ?)", phrase)
+	}
+
+	// NOTE: Like in the search index, should terms are optional unless nothing else selects films.
+	if len(p.Should) > 0 && p.Query == "" && len(p.Must) == 0 && len(p.Phrases) == 0 &&
+		p.YearFrom == nil && p.YearTo == nil && p.RatingMin == nil && p.RatingMax == nil {
+		ors := make([]string, len(p.Should))
+		for i, term := range p.Should {
+			ors[i] = "search @@ plainto_tsquery('english', " + conds.placeholder(term) + ")"
+		}
+		conds.addClause("(" + strings.Join(ors, " OR ") + ")")
+	}
+
+	if p.YearFrom != nil {
+		conds.add("release_year >= ?", *p.YearFrom)
+	}
+	if p.YearTo != nil {
+		conds.add("release_year <= ?", *p.YearTo)
+	}
+	if p.RatingMin != nil {
+		conds.add("rating >= ?", *p.RatingMin)
+	}
+	if p.RatingMax != nil {
+		conds.add("rating <= ?", *p.RatingMax)
+	}
+
+	if len(p.Decades) > 0 && excluded != models.FacetDecade {
+		ors := make([]string, len(p.Decades))
+		for i, d := range p.Decades {
+			ors[i] = "release_year BETWEEN " + conds.placeholder(d) + " AND " + conds.placeholder(d+9)
+		}
+		conds.addClause("(" + strings.Join(ors, " OR ") + ")")
+	}
+
+	if len(p.Ratings) > 0 && excluded != models.FacetRating {
+		ors := make([]string, 0, len(p.Ratings))
+		for _, rr := range m.RatingRanges {
+			if slices.Contains(p.Ratings, rr.Key) {
+				ors = append(ors, "("+ratingClause(conds, rr)+")")
+			}
+		}
+		conds.addClause("(" + strings.Join(ors, " OR ") + ")")
+	}
+}
+
+// ratingClause returns the clause selecting the films in the rating bucket, the highest bucket
+// includes the highest rating.
+func ratingClause(conds *conditions, rr m.RatingRange) string {
+	clause := "rating >= " + conds.placeholder(rr.From)
+	if rr.To < 10 {
+		clause += " AND rating < " + conds.placeholder(rr.To)
+	}
+
+	return clause
+}
+
+// searchScore returns the expression scoring the films found by p.
+func searchScore(conds *conditions, p m.SearchFilms) string {
+	weights := rankWeights(p.Boosts)
+
+	ranks := []string{"0"}
+	rank := func(tsquery string, arg interface{}) {
+		ranks = append(ranks, "ts_rank_cd("+weights+", search, "+
+			strings.ReplaceAll(tsquery, "?", conds.placeholder(arg))+")")
+	}
+
+	if p.Query != "" {
+		rank(anyTerm, p.Query)
+		if fuzzy(p) {
+			ranks = append(ranks, "similarity(name, "+conds.placeholder(p.Query)+")")
+		}
+	}
+	for _, term := range p.Must {
+		rank("plainto_tsquery('english', ?)", term)
+	}
+	for _, term := range p.Should {
+		rank("plainto_tsquery('english', ?)", term)
+	}
+	for _, phrase := range p.Phrases {
+		rank("phraseto_tsquery('english', ?)", phrase)
+	}
+
+	return strings.Join(ranks, " + ")
+}
+
+// rankWeights returns the ts_rank_cd weights of the boosts: names are indexed with weight A,
+// descriptions with B. Weights can't exceed 1 so the boosts are scaled down to the highest one.
+func rankWeights(boosts map[string]float32) string {
+	name, description := float32(2), float32(1)
+	if b, ok := boosts["name"]; ok {
+		name = b
+	}
+	if b, ok := boosts["description"]; ok {
+		description = b
+	}
+
+	highest := max(name, description)
+	format := func(w float32) string {
+		return strconv.FormatFloat(float64(w/highest), 'f', -1, 32)
+	}
+
+	// NOTE: The weights are in D, C, B, A order.
+	return "'{0, 0, " + format(description) + ", " + format(name) + "}'::real[]"
+}
+
+// fuzzy reports whether p tolerates typos.
+func fuzzy(p m.SearchFilms) bool {
+	return p.Fuzziness != "0"
+}
+
+// sortValues returns the values of the sort fields of hit, searching after them returns the
+// next page.
+func sortValues(sort []m.SortField, hit models.FilmHit, score float32) []interface{} {
+	values := make([]interface{}, len(sort))
+	for i, s := range sort {
+		switch s.Name {
+		case "score":
+			values[i] = score
+		case "rating":
+			values[i] = hit.Rating
+		case "release_year":
+			values[i] = hit.ReleaseYear
+		case "id":
+			values[i] = hit.Id
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"filmoteka/internal/envvar"
+)
+
+// recorder records the spans of every test, the global tracer provider is only set once.
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	if _, err := Setup(context.Background(), envvar.TracingConfig{Exporter: ExporterNone}); err != nil {
+		panic(err)
+	}
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	os.Exit(m.Run())
+}
+
+// ended returns the spans ended since the test recorded before.
+func ended(before int) []sdktrace.ReadOnlySpan {
+	return recorder.Ended()[before:]
+}
+
+func TestMiddleware(t *testing.T) {
+	before := len(recorder.Ended())
+
+	// NOTE: The trace of the caller, in W3C trace context format.
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/films/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods(http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, "/films/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	spans := ended(before)
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /films/{id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, parentID, span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	assert.Equal(t, "00-"+traceID+"-"+span.SpanContext().SpanID().String()+"-01", w.Header().Get("traceparent"))
+}
+
+func TestTransport(t *testing.T) {
+	before := len(recorder.Ended())
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/films/_search", nil)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	parent.End()
+
+	spans := ended(before)
+	require.Len(t, spans, 2)
+
+	span := spans[0]
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01", received)
+	assert.Empty(t, req.Header.Get("traceparent"), "the request sent must not be modified")
+}